@@ -0,0 +1,530 @@
+package dd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cybergodev/dd/internal/filewriter"
+)
+
+// Audit mode frames every record written by a FileWriter with a header line
+//
+//	#dd-audit seq=<n> len=<bytes> hash=<hex>
+//
+// where hash chains the previous record's hash, the sequence number and the
+// record bytes through SHA-256, or HMAC-SHA256 when a key is configured.
+// Before each rotation a checkpoint line seals the file, and an anchor line
+// carrying the same chain state opens the next one:
+//
+//	#dd-audit checkpoint seq=<n> hash=<hex> sig=<hex>
+//	#dd-audit anchor seq=<n> hash=<hex> sig=<hex>
+//
+// The anchor lets the chain be verified from the oldest retained file once
+// older backups have been removed.
+//
+// When audit mode is switched on for a file that already holds unframed
+// lines, a segment line marks where the chain continues:
+//
+//	#dd-audit segment seq=<n> hash=<hex> sig=<hex>
+//
+// Without a key every hash and signature can be recomputed by anyone who can
+// edit the file, so unkeyed chains only detect accidental damage such as
+// truncation or corruption. Set an AuditKey to detect deliberate tampering.
+const (
+	auditPrefix           = "#dd-audit "
+	auditRecordPrefix     = "#dd-audit seq="
+	auditCheckpointPrefix = "#dd-audit checkpoint seq="
+	auditAnchorPrefix     = "#dd-audit anchor seq="
+	auditSegmentPrefix    = "#dd-audit segment seq="
+	auditCheckpointDomain = "dd-audit-checkpoint"
+	auditAnchorDomain     = "dd-audit-anchor"
+	auditSegmentDomain    = "dd-audit-segment"
+
+	// auditTailChunk is how much of a file is read at a time when searching
+	// backwards for the last audit item.
+	auditTailChunk = 64 * 1024
+)
+
+// AuditReport summarizes a successfully verified audit log.
+type AuditReport struct {
+	// Files lists the verified files, oldest first
+	Files []string
+	// Records is the number of records verified
+	Records uint64
+	// FirstSeq is the sequence number of the oldest retained record. It is
+	// greater than 1 when older backups have been removed by retention, in
+	// which case the anchor line opening the oldest retained file links it
+	// to the removed records.
+	FirstSeq uint64
+	// LastSeq is the sequence number of the newest record
+	LastSeq uint64
+	// Checkpoints is the number of rotation checkpoints verified
+	Checkpoints int
+	// Segments is the number of places where the chain continues after
+	// unframed lines written before audit mode was enabled
+	Segments int
+}
+
+// auditKind tells records apart from the signed lines that carry the chain
+// state.
+type auditKind int8
+
+const (
+	auditItemRecord     auditKind = iota
+	auditItemCheckpoint           // seals a file before rotation
+	auditItemAnchor               // opens a file after rotation
+	auditItemSegment              // continues the chain after unframed lines
+)
+
+// auditSigned maps each signed line kind to its prefix and signature domain.
+var auditSigned = map[auditKind]struct{ name, prefix, domain string }{
+	auditItemCheckpoint: {"checkpoint", auditCheckpointPrefix, auditCheckpointDomain},
+	auditItemAnchor:     {"anchor", auditAnchorPrefix, auditAnchorDomain},
+	auditItemSegment:    {"segment", auditSegmentPrefix, auditSegmentDomain},
+}
+
+// auditChain holds the running state of a hash chain.
+type auditChain struct {
+	key  []byte
+	seq  uint64
+	prev [sha256.Size]byte
+}
+
+func newAuditChain(key []byte) *auditChain {
+	return &auditChain{key: key}
+}
+
+func (c *auditChain) newHash() hash.Hash {
+	if len(c.key) > 0 {
+		return hmac.New(sha256.New, c.key)
+	}
+	return sha256.New()
+}
+
+// recordHash returns the chained hash of body at seq following prev.
+func (c *auditChain) recordHash(prev [sha256.Size]byte, seq uint64, body []byte) [sha256.Size]byte {
+	h := c.newHash()
+	h.Write(prev[:])
+	var seqBuf [8]byte
+	binary.BigEndian.PutUint64(seqBuf[:], seq)
+	h.Write(seqBuf[:])
+	h.Write(body)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// checkpointSig signs the chain state at seq for the signed line kind
+// given by domain.
+func (c *auditChain) checkpointSig(domain string, seq uint64, sum [sha256.Size]byte) []byte {
+	h := c.newHash()
+	h.Write([]byte(domain))
+	var seqBuf [8]byte
+	binary.BigEndian.PutUint64(seqBuf[:], seq)
+	h.Write(seqBuf[:])
+	h.Write(sum[:])
+	return h.Sum(nil)
+}
+
+// frame advances the chain and returns body preceded by its header.
+func (c *auditChain) frame(body []byte) []byte {
+	c.seq++
+	c.prev = c.recordHash(c.prev, c.seq, body)
+
+	header := fmt.Sprintf("%s%d len=%d hash=%s\n", auditRecordPrefix, c.seq, len(body), hex.EncodeToString(c.prev[:]))
+	out := make([]byte, 0, len(header)+len(body))
+	out = append(out, header...)
+	return append(out, body...)
+}
+
+// signedLine returns a line of the given kind carrying the current chain
+// state: a checkpoint sealing a file, an anchor opening the next one, or a
+// segment continuing the chain after unframed lines.
+func (c *auditChain) signedLine(kind auditKind) []byte {
+	signed := auditSigned[kind]
+	sig := c.checkpointSig(signed.domain, c.seq, c.prev)
+	return []byte(fmt.Sprintf("%s%d hash=%s sig=%s\n", signed.prefix, c.seq, hex.EncodeToString(c.prev[:]), hex.EncodeToString(sig)))
+}
+
+// auditItem is one parsed record or signed line.
+type auditItem struct {
+	kind   auditKind
+	seq    uint64
+	sum    [sha256.Size]byte
+	sig    []byte
+	body   []byte
+	length int // body length of a record
+}
+
+// parseAuditHeader parses a record header or signed line without its
+// trailing newline. Record bodies are not read.
+func parseAuditHeader(line string) (auditItem, error) {
+	var item auditItem
+	var sumHex, sigHex string
+	if strings.HasPrefix(line, auditRecordPrefix) {
+		if _, err := fmt.Sscanf(line, auditRecordPrefix+"%d len=%d hash=%s", &item.seq, &item.length, &sumHex); err != nil || item.length < 0 {
+			return item, fmt.Errorf("malformed header %q", line)
+		}
+	} else {
+		item.kind = auditItemRecord
+		for kind, signed := range auditSigned {
+			if strings.HasPrefix(line, signed.prefix) {
+				item.kind = kind
+				if _, err := fmt.Sscanf(line, signed.prefix+"%d hash=%s sig=%s", &item.seq, &sumHex, &sigHex); err != nil {
+					return item, fmt.Errorf("malformed %s %q", signed.name, line)
+				}
+				break
+			}
+		}
+		if item.kind == auditItemRecord {
+			return item, fmt.Errorf("unexpected line %q", line)
+		}
+		sig, err := hex.DecodeString(sigHex)
+		if err != nil {
+			return item, fmt.Errorf("malformed %s %q", auditSigned[item.kind].name, line)
+		}
+		item.sig = sig
+	}
+
+	sum, err := hex.DecodeString(sumHex)
+	if err != nil || len(sum) != sha256.Size {
+		return item, fmt.Errorf("malformed hash in %q", line)
+	}
+	copy(item.sum[:], sum)
+	return item, nil
+}
+
+// scanAuditItems parses r and calls fn for every item. Unframed lines are
+// accepted only in front of a segment line or when r holds no items at all,
+// as in a log written before audit mode was enabled.
+func scanAuditItems(r io.Reader, fn func(auditItem)) error {
+	br := bufio.NewReader(r)
+	items := 0
+	unframed := "" // first unframed line not yet followed by a segment
+	for {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line == "" {
+			if unframed != "" && items > 0 {
+				return fmt.Errorf("unexpected line %q", unframed)
+			}
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		complete := strings.HasSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\n")
+
+		if !strings.HasPrefix(line, auditPrefix) {
+			if unframed == "" {
+				unframed = line
+			}
+			continue
+		}
+		if !complete {
+			return fmt.Errorf("truncated header %q", line)
+		}
+
+		item, err := parseAuditHeader(line)
+		if err != nil {
+			return err
+		}
+		if unframed != "" && item.kind != auditItemSegment {
+			return fmt.Errorf("unexpected line %q", unframed)
+		}
+		unframed = ""
+		if item.kind == auditItemRecord {
+			item.body = make([]byte, item.length)
+			if _, err := io.ReadFull(br, item.body); err != nil {
+				return fmt.Errorf("record %d: truncated body", item.seq)
+			}
+		}
+		items++
+		fn(item)
+	}
+}
+
+// readAuditItems parses every item in r.
+func readAuditItems(r io.Reader) ([]auditItem, error) {
+	var items []auditItem
+	err := scanAuditItems(r, func(item auditItem) { items = append(items, item) })
+	return items, err
+}
+
+// openAuditFile opens an audit file, transparently decompressing .gz files.
+func openAuditFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gr, f}, nil
+}
+
+// readAuditFile parses an audit file, transparently decompressing .gz files.
+func readAuditFile(path string) ([]auditItem, error) {
+	r, err := openAuditFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readAuditItems(r)
+}
+
+// auditTail describes the end of an audit file.
+type auditTail struct {
+	// last is the last item of the file, nil if it holds none
+	last *auditItem
+	// unframed is set when the file holds content after last, or only
+	// unframed content
+	unframed bool
+	// newline is set when the file is empty or ends with a newline
+	newline bool
+}
+
+// readAuditTail finds the last item of an audit file. Plain files are
+// searched backwards from the end so that only their tail is read;
+// compressed backups are streamed.
+func readAuditTail(path string) (auditTail, error) {
+	tail := auditTail{newline: true}
+	if strings.HasSuffix(path, ".gz") {
+		r, err := openAuditFile(path)
+		if err != nil {
+			return tail, err
+		}
+		defer r.Close()
+		err = scanAuditItems(r, func(item auditItem) {
+			item.body = nil
+			tail.last = &item
+		})
+		return tail, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return tail, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return tail, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return tail, nil
+	}
+
+	var lastByte [1]byte
+	if _, err := f.ReadAt(lastByte[:], size-1); err != nil {
+		return tail, err
+	}
+	tail.newline = lastByte[0] == '\n'
+
+	item, end, err := lastAuditItem(f, size)
+	if err != nil {
+		return tail, err
+	}
+	tail.last = item
+	tail.unframed = end < size
+	return tail, nil
+}
+
+// lastAuditItem searches f backwards, one chunk at a time, for the last
+// line that parses as an audit header and whose item fits in the file. It
+// returns the item and the offset just past it, or nil and 0 if there is
+// none.
+func lastAuditItem(f *os.File, size int64) (*auditItem, int64, error) {
+	pos := size
+	var carry []byte // start of the line continuing into the chunk read before
+	for pos > 0 {
+		n := min(int64(auditTailChunk), pos)
+		pos -= n
+		buf := make([]byte, n, n+int64(len(carry)))
+		if _, err := f.ReadAt(buf, pos); err != nil {
+			return nil, 0, err
+		}
+		buf = append(buf, carry...)
+
+		for limit := len(buf); ; {
+			nl := bytes.LastIndexByte(buf[:limit], '\n')
+			if nl < 0 && pos > 0 {
+				break // the first line may start in the previous chunk
+			}
+			start := nl + 1
+			if lineLen := bytes.IndexByte(buf[start:], '\n'); lineLen >= 0 && bytes.HasPrefix(buf[start:], []byte(auditPrefix)) {
+				if item, err := parseAuditHeader(string(buf[start : start+lineLen])); err == nil {
+					end := pos + int64(start+lineLen+1)
+					if item.kind == auditItemRecord {
+						end += int64(item.length)
+					}
+					if end <= size {
+						return &item, end, nil
+					}
+				}
+			}
+			if nl < 0 {
+				break
+			}
+			limit = nl
+		}
+
+		if first := bytes.IndexByte(buf, '\n'); first >= 0 {
+			carry = buf[:first+1]
+		} else {
+			carry = buf
+		}
+	}
+	return nil, 0, nil
+}
+
+// resumeAuditChain restores the chain state from the newest file of the log
+// that holds audit items, so that a restarted writer continues the existing
+// chain. If the current file ends with unframed lines, e.g. because audit
+// mode was just switched on for an existing log, it also returns a segment
+// line to append before the next record.
+func resumeAuditChain(path string, key []byte) (*auditChain, []byte, error) {
+	chain := newAuditChain(key)
+	files := append(filewriter.ListBackups(path), path)
+	needSegment, needNewline := false, false
+	for i := len(files) - 1; i >= 0; i-- {
+		tail, err := readAuditTail(files[i])
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("resume audit chain from %s: %w", files[i], err)
+		}
+		if i == len(files)-1 {
+			needSegment, needNewline = tail.unframed, !tail.newline
+		}
+		if tail.last != nil {
+			chain.seq, chain.prev = tail.last.seq, tail.last.sum
+			break
+		}
+	}
+
+	if !needSegment {
+		return chain, nil, nil
+	}
+	segment := chain.signedLine(auditItemSegment)
+	if needNewline {
+		segment = append([]byte("\n"), segment...)
+	}
+	return chain, segment, nil
+}
+
+// VerifyAuditLog checks the audit chain of the log at path and all of its
+// rotated backups, including gzip-compressed ones. It reports gaps,
+// reordered or edited records, forged checkpoints and rotated files that
+// were truncated, and a chain whose oldest retained file neither starts at
+// the first record nor opens with a signed anchor. key must match the FileWriterConfig.AuditKey used to write
+// the log (nil for plain SHA-256 chains, which anyone able to edit the log
+// can recompute, so they only detect accidental damage). Unframed lines
+// written before audit mode was enabled are accepted where a segment line
+// marks the start of the chain.
+//
+// Records removed from the tail of the newest file cannot be detected from
+// the log alone; compare LastSeq with an externally stored value for that.
+func VerifyAuditLog(path string, key []byte) (*AuditReport, error) {
+	securePath, err := validateAndSecurePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := filewriter.ListBackups(securePath)
+	if _, err := os.Stat(securePath); err == nil {
+		files = append(files, securePath)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no log files found for %s", ErrAuditVerification, securePath)
+	}
+
+	chain := newAuditChain(key)
+	report := &AuditReport{}
+	started := false
+
+	for i, file := range files {
+		items, err := readAuditFile(file)
+		if err != nil {
+			return report, fmt.Errorf("%w: %s: %w", ErrAuditVerification, file, err)
+		}
+		report.Files = append(report.Files, file)
+
+		for _, item := range items {
+			if item.kind != auditItemRecord {
+				signed := auditSigned[item.kind]
+				if item.kind == auditItemCheckpoint && !started || started && (item.seq != chain.seq || item.sum != chain.prev) {
+					return report, fmt.Errorf("%w: %s: %s at seq %d does not match the chain", ErrAuditVerification, file, signed.name, item.seq)
+				}
+				if !hmac.Equal(item.sig, chain.checkpointSig(signed.domain, item.seq, item.sum)) {
+					return report, fmt.Errorf("%w: %s: invalid %s signature at seq %d", ErrAuditVerification, file, signed.name, item.seq)
+				}
+				if !started {
+					// An anchor or segment carries the state of records
+					// that are no longer retained.
+					started = true
+					chain.seq, chain.prev = item.seq, item.sum
+				}
+				switch item.kind {
+				case auditItemCheckpoint:
+					report.Checkpoints++
+				case auditItemSegment:
+					report.Segments++
+				}
+				continue
+			}
+
+			if report.Records == 0 {
+				report.FirstSeq = item.seq
+			}
+			if !started {
+				started = true
+				if item.seq != 1 {
+					return report, fmt.Errorf("%w: %s: record %d is not anchored; earlier records are missing", ErrAuditVerification, file, item.seq)
+				}
+			}
+
+			switch {
+			case item.seq <= chain.seq:
+				return report, fmt.Errorf("%w: %s: record %d out of order after %d", ErrAuditVerification, file, item.seq, chain.seq)
+			case item.seq != chain.seq+1:
+				return report, fmt.Errorf("%w: %s: gap between records %d and %d", ErrAuditVerification, file, chain.seq, item.seq)
+			case chain.recordHash(chain.prev, item.seq, item.body) != item.sum:
+				return report, fmt.Errorf("%w: %s: record %d was modified", ErrAuditVerification, file, item.seq)
+			}
+			chain.seq, chain.prev = item.seq, item.sum
+			report.Records++
+		}
+
+		// Files holding only unframed lines predate audit mode
+		legacy := len(items) == 0 && !started
+		if i < len(files)-1 && !legacy && (len(items) == 0 || items[len(items)-1].kind != auditItemCheckpoint) {
+			return report, fmt.Errorf("%w: %s: rotated file does not end with a checkpoint", ErrAuditVerification, file)
+		}
+	}
+
+	report.LastSeq = chain.seq
+	return report, nil
+}
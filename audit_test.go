@@ -0,0 +1,283 @@
+package dd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybergodev/dd/internal/filewriter"
+)
+
+// ============================================================================
+// AUDIT LOG TESTS
+// ============================================================================
+
+var testAuditKey = []byte("audit-test-key")
+
+// writeAuditLog writes records to an audit-mode FileWriter, rotating after
+// every rotateEvery records.
+func writeAuditLog(t *testing.T, path string, config FileWriterConfig, records, rotateEvery int) {
+	t.Helper()
+	config.Audit = true
+	fw, err := NewFileWriter(path, config)
+	if err != nil {
+		t.Fatalf("Failed to create file writer: %v", err)
+	}
+	for i := 1; i <= records; i++ {
+		if _, err := fmt.Fprintf(fw, "record %d\n", i); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if rotateEvery > 0 && i%rotateEvery == 0 {
+			fw.mu.Lock()
+			err := fw.rotate()
+			fw.mu.Unlock()
+			if err != nil {
+				t.Fatalf("Rotate failed: %v", err)
+			}
+		}
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestAuditLogVerifiesAcrossRotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey, Compress: true}, 10, 4)
+
+	if backups := filewriter.ListBackups(path); len(backups) != 2 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("Expected two compressed backups, got %v", backups)
+	}
+
+	report, err := VerifyAuditLog(path, testAuditKey)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog failed: %v", err)
+	}
+	if report.Records != 10 || report.FirstSeq != 1 || report.LastSeq != 10 || report.Checkpoints != 2 || len(report.Files) != 3 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	if _, err := VerifyAuditLog(path, []byte("wrong key")); !errors.Is(err, ErrAuditVerification) {
+		t.Errorf("Expected verification to fail with the wrong key, got %v", err)
+	}
+}
+
+func TestAuditLogResumesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, path, FileWriterConfig{}, 3, 3)
+	writeAuditLog(t, path, FileWriterConfig{}, 2, 0)
+
+	report, err := VerifyAuditLog(path, nil)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog failed: %v", err)
+	}
+	if report.Records != 5 || report.LastSeq != 5 {
+		t.Errorf("Expected the restarted writer to continue the chain, got %+v", report)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(data, []byte("#dd-audit anchor seq=3 hash=")) || !bytes.Contains(data, []byte("\n#dd-audit seq=4 len=9 hash=")) {
+		t.Errorf("Unexpected framing: %q", data)
+	}
+}
+
+func TestAuditLogEnabledOnExistingLog(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, path string)
+		want  AuditReport
+	}{
+		{"plain log", func(t *testing.T, path string) {
+			if err := os.WriteFile(path, []byte("plain 1\nplain 2\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}, AuditReport{Records: 2, FirstSeq: 1, LastSeq: 2, Segments: 1}},
+		{"missing newline", func(t *testing.T, path string) {
+			if err := os.WriteFile(path, []byte("plain 1"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}, AuditReport{Records: 2, FirstSeq: 1, LastSeq: 2, Segments: 1}},
+		{"audit switched off and on", func(t *testing.T, path string) {
+			writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey}, 3, 0)
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = f.WriteString("unaudited\n")
+			_ = f.Close()
+		}, AuditReport{Records: 5, FirstSeq: 1, LastSeq: 5, Segments: 1}},
+		{"large unaudited tail", func(t *testing.T, path string) {
+			writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey}, 3, 0)
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = f.WriteString(strings.Repeat(strings.Repeat("y", 999)+"\n", 3*auditTailChunk/1000))
+			_ = f.Close()
+		}, AuditReport{Records: 5, FirstSeq: 1, LastSeq: 5, Segments: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			tt.setup(t, path)
+			writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey}, 2, 0)
+
+			report, err := VerifyAuditLog(path, testAuditKey)
+			if err != nil {
+				t.Fatalf("VerifyAuditLog failed: %v", err)
+			}
+			if report.Records != tt.want.Records || report.FirstSeq != tt.want.FirstSeq ||
+				report.LastSeq != tt.want.LastSeq || report.Segments != tt.want.Segments {
+				t.Errorf("Unexpected report: %+v", report)
+			}
+		})
+	}
+}
+
+func TestAuditLogRejectsUnframedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey}, 3, 0)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	tampered := strings.Replace(string(data), auditRecord(string(data), 2), "injected\n"+auditRecord(string(data), 2), 1)
+	if err := os.WriteFile(path, []byte(tampered), 0600); err != nil {
+		t.Fatalf("Failed to tamper: %v", err)
+	}
+
+	if _, err := VerifyAuditLog(path, testAuditKey); !errors.Is(err, ErrAuditVerification) || !strings.Contains(err.Error(), "unexpected line") {
+		t.Errorf("Expected an unexpected line error, got %v", err)
+	}
+}
+
+func TestAuditLogResumesFromLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	body := strings.Repeat("x", 1000) + "\n"
+	config := FileWriterConfig{Audit: true, AuditKey: testAuditKey}
+	fw, err := NewFileWriter(path, config)
+	if err != nil {
+		t.Fatalf("Failed to create file writer: %v", err)
+	}
+	for i := 0; i < 3*auditTailChunk/len(body); i++ {
+		if _, err := fw.Write([]byte(body)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	want := fw.audit.seq
+	_ = fw.Close()
+
+	chain, segment, err := resumeAuditChain(path, testAuditKey)
+	if err != nil {
+		t.Fatalf("resumeAuditChain failed: %v", err)
+	}
+	if chain.seq != want || segment != nil {
+		t.Errorf("Expected to resume at seq %d without a segment, got seq %d, segment %q", want, chain.seq, segment)
+	}
+}
+
+func TestAuditLogDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(data string) string
+		want   string
+	}{
+		{"edit", func(d string) string { return strings.Replace(d, "record 2\n", "record X\n", 1) }, "modified"},
+		{"delete", func(d string) string { return removeAuditRecord(d, 2) }, "gap"},
+		{"reorder", func(d string) string {
+			second := auditRecord(d, 2)
+			return strings.Replace(removeAuditRecord(d, 2), auditRecord(d, 3), auditRecord(d, 3)+second, 1)
+		}, "gap"},
+		{"replay", func(d string) string {
+			return strings.Replace(d, auditRecord(d, 3), auditRecord(d, 3)+auditRecord(d, 2), 1)
+		}, "out of order"},
+		{"truncate", func(d string) string { return d[:strings.Index(d, "#dd-audit checkpoint")] }, "checkpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey}, 6, 4)
+
+			backup := filewriter.ListBackups(path)[0]
+			data, err := os.ReadFile(backup)
+			if err != nil {
+				t.Fatalf("Failed to read backup: %v", err)
+			}
+			if err := os.WriteFile(backup, []byte(tt.tamper(string(data))), 0600); err != nil {
+				t.Fatalf("Failed to tamper: %v", err)
+			}
+
+			_, err = VerifyAuditLog(path, testAuditKey)
+			if !errors.Is(err, ErrAuditVerification) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %q verification error, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAuditLogDetectsHeadRemoval(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(data string) string
+		want   string
+	}{
+		{"retention", func(d string) string { return d }, ""},
+		{"first record", func(d string) string { return removeAuditRecord(d, 5) }, "gap"},
+		{"anchor and records", func(d string) string {
+			return d[strings.Index(d, "#dd-audit seq=7 "):]
+		}, "not anchored"},
+		{"edited anchor", func(d string) string { return strings.Replace(d, "anchor seq=4 ", "anchor seq=5 ", 1) }, "signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			writeAuditLog(t, path, FileWriterConfig{AuditKey: testAuditKey}, 10, 4)
+
+			// Drop the oldest backup as retention would
+			backups := filewriter.ListBackups(path)
+			if err := os.Remove(backups[0]); err != nil {
+				t.Fatalf("Failed to remove backup: %v", err)
+			}
+			data, err := os.ReadFile(backups[1])
+			if err != nil {
+				t.Fatalf("Failed to read backup: %v", err)
+			}
+			if err := os.WriteFile(backups[1], []byte(tt.tamper(string(data))), 0600); err != nil {
+				t.Fatalf("Failed to tamper: %v", err)
+			}
+
+			report, err := VerifyAuditLog(path, testAuditKey)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("VerifyAuditLog failed: %v", err)
+				}
+				if report.FirstSeq != 5 || report.LastSeq != 10 || report.Records != 6 {
+					t.Errorf("Unexpected report: %+v", report)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAuditVerification) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %q verification error, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// auditRecord returns the framed record with the given sequence number.
+func auditRecord(data string, seq int) string {
+	start := strings.Index(data, fmt.Sprintf("#dd-audit seq=%d ", seq))
+	end := strings.Index(data[start+1:], "#dd-audit")
+	return data[start : start+1+end]
+}
+
+func removeAuditRecord(data string, seq int) string {
+	return strings.Replace(data, auditRecord(data, seq), "", 1)
+}
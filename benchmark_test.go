@@ -3,6 +3,7 @@ package dd
 import (
 	"bytes"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -487,6 +488,18 @@ func BenchmarkSecureFilter(b *testing.B) {
 	}
 }
 
+func BenchmarkSecureFilterLargeInput(b *testing.B) {
+	filter := NewSensitiveDataFilter()
+	message := strings.Repeat("request handled for user 42 in 3ms; ", 100) +
+		"password: secret123 and card 4532015112830366"
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = filter.Filter(message)
+	}
+}
+
 // ============================================================================
 // MESSAGE SIZE BENCHMARKS
 // ============================================================================
@@ -46,6 +46,27 @@ type LoggerConfig struct {
 	SecurityConfig *SecurityConfig
 	FatalHandler   FatalHandler
 	JSON           *JSONOptions
+
+	// Color wraps the level label of text output in the level's ANSI color
+	Color bool
+
+	// ErrorHandler is notified of every failed write (optional)
+	ErrorHandler ErrorHandler
+	// FallbackWriter receives records that a primary writer failed to
+	// accept (defaults to os.Stderr; use io.Discard to drop them)
+	FallbackWriter io.Writer
+	// WriterFailureThreshold is the number of consecutive failures after
+	// which a writer is disabled (0 uses the default, negative never disables)
+	WriterFailureThreshold int
+	// WriterRetryInterval is how long a disabled writer is skipped before
+	// it is retried
+	WriterRetryInterval time.Duration
+
+	// FlightRecorder, if set, captures records below Level (down to
+	// FlightRecorderLevel) and writes them out ahead of the next ERROR or
+	// FATAL record
+	FlightRecorder      *RingBufferWriter
+	FlightRecorderLevel LogLevel
 }
 
 func DefaultConfig() *LoggerConfig {
@@ -111,7 +132,15 @@ func (c *LoggerConfig) Clone() *LoggerConfig {
 		IncludeLevel:  c.IncludeLevel,
 		FullPath:      c.FullPath,
 		DynamicCaller: c.DynamicCaller,
+		Color:         c.Color,
 		FatalHandler:  c.FatalHandler,
+
+		ErrorHandler:           c.ErrorHandler,
+		FallbackWriter:         c.FallbackWriter,
+		WriterFailureThreshold: c.WriterFailureThreshold,
+		WriterRetryInterval:    c.WriterRetryInterval,
+		FlightRecorder:         c.FlightRecorder,
+		FlightRecorderLevel:    c.FlightRecorderLevel,
 	}
 
 	if len(c.Writers) > 0 {
@@ -153,10 +182,14 @@ func (c *LoggerConfig) Validate() error {
 		return ErrNilConfig
 	}
 
-	if c.Level < LevelDebug || c.Level > LevelFatal {
+	if !c.Level.IsValid() {
 		return fmt.Errorf("%w: %d", ErrInvalidLevel, c.Level)
 	}
 
+	if c.FlightRecorder != nil && !c.FlightRecorderLevel.IsValid() {
+		return fmt.Errorf("%w: flight recorder level %d", ErrInvalidLevel, c.FlightRecorderLevel)
+	}
+
 	if c.Format != FormatText && c.Format != FormatJSON {
 		return fmt.Errorf("%w: %d", ErrInvalidFormat, c.Format)
 	}
@@ -169,6 +202,18 @@ func (c *LoggerConfig) Validate() error {
 		c.Writers = []io.Writer{os.Stdout}
 	}
 
+	if c.FallbackWriter == nil {
+		c.FallbackWriter = os.Stderr
+	}
+
+	if c.WriterFailureThreshold == 0 {
+		c.WriterFailureThreshold = DefaultWriterFailureThreshold
+	}
+
+	if c.WriterRetryInterval <= 0 {
+		c.WriterRetryInterval = DefaultWriterRetryInterval
+	}
+
 	if c.SecurityConfig == nil {
 		c.SecurityConfig = DefaultSecurityConfig()
 	} else {
@@ -0,0 +1,537 @@
+package dd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileConfig is the on-disk (JSON) representation of a logger configuration.
+// Level, filter and sink settings can be hot-reloaded via ConfigWatcher;
+// format and caller settings are only applied when the logger is created.
+type FileConfig struct {
+	Level          string       `json:"level"`
+	Format         string       `json:"format"`
+	TimeFormat     string       `json:"time_format"`
+	IncludeCaller  bool         `json:"include_caller"`
+	FullPath       bool         `json:"full_path"`
+	DynamicCaller  bool         `json:"dynamic_caller"`
+	Color          bool         `json:"color"`
+	FilterLevel    string       `json:"filter_level"`
+	FilterPatterns []string     `json:"filter_patterns"`
+	MaxMessageSize int          `json:"max_message_size"`
+	Sinks          []SinkConfig `json:"sinks"`
+}
+
+// SinkConfig describes a single named output in a FileConfig.
+// Type is one of "stdout", "stderr" or "file".
+type SinkConfig struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Path       string   `json:"path,omitempty"`
+	MaxSizeMB  int      `json:"max_size_mb,omitempty"`
+	MaxAge     Duration `json:"max_age,omitempty"`
+	MaxBackups int      `json:"max_backups,omitempty"`
+	Compress   bool     `json:"compress,omitempty"`
+}
+
+// Duration is a time.Duration that unmarshals from strings such as "720h".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if numErr := json.Unmarshal(data, &n); numErr != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+const (
+	sinkTypeStdout = "stdout"
+	sinkTypeStderr = "stderr"
+	sinkTypeFile   = "file"
+)
+
+// LoadConfigFile reads and validates a JSON logger configuration file.
+func LoadConfigFile(path string) (*FileConfig, error) {
+	securePath, err := validateAndSecurePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(securePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+	}
+
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a JSON logger configuration.
+func ParseConfig(data []byte) (*FileConfig, error) {
+	var fc FileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+	}
+
+	if err := fc.Validate(); err != nil {
+		return nil, err
+	}
+	return &fc, nil
+}
+
+// Validate checks the configuration without opening any sinks.
+func (fc *FileConfig) Validate() error {
+	if fc == nil {
+		return ErrNilConfig
+	}
+
+	if _, err := parseLevelName(fc.Level); err != nil {
+		return err
+	}
+	if _, err := parseFormatName(fc.Format); err != nil {
+		return err
+	}
+	if _, err := fc.buildFilter(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(fc.Sinks))
+	for i, sink := range fc.Sinks {
+		if sink.Name == "" {
+			return fmt.Errorf("%w: sink[%d] has no name", ErrInvalidConfigFile, i)
+		}
+		if seen[sink.Name] {
+			return fmt.Errorf("%w: duplicate sink name %q", ErrInvalidConfigFile, sink.Name)
+		}
+		seen[sink.Name] = true
+
+		switch sink.Type {
+		case sinkTypeStdout, sinkTypeStderr:
+		case sinkTypeFile:
+			if _, err := validateAndSecurePath(sink.Path); err != nil {
+				return fmt.Errorf("sink %q: %w", sink.Name, err)
+			}
+			fwConfig := sink.fileWriterConfig()
+			if err := validateFileWriterConfig(&fwConfig); err != nil {
+				return fmt.Errorf("sink %q: %w", sink.Name, err)
+			}
+		default:
+			return fmt.Errorf("%w: %q (sink %q)", ErrUnknownSinkType, sink.Type, sink.Name)
+		}
+	}
+
+	return nil
+}
+
+// LoggerConfig converts the file configuration into a LoggerConfig, opening
+// all configured sinks. Sinks opened here are not tracked by a ConfigWatcher;
+// use NewConfigWatcher for reloadable sinks.
+func (fc *FileConfig) LoggerConfig() (*LoggerConfig, error) {
+	if err := fc.Validate(); err != nil {
+		return nil, err
+	}
+
+	level, _ := parseLevelName(fc.Level)
+	format, _ := parseFormatName(fc.Format)
+	filter, _ := fc.buildFilter()
+
+	config := DefaultConfig()
+	config.Level = level
+	config.Format = format
+	config.IncludeCaller = fc.IncludeCaller
+	config.FullPath = fc.FullPath
+	config.DynamicCaller = fc.DynamicCaller
+	config.Color = fc.Color
+	if fc.TimeFormat != "" {
+		config.TimeFormat = fc.TimeFormat
+	}
+	if format == FormatJSON {
+		config.JSON = DefaultJSONOptions()
+	}
+	config.SecurityConfig = fc.securityConfig(filter)
+
+	writers := make([]io.Writer, 0, len(fc.Sinks))
+	for _, sink := range fc.Sinks {
+		w, err := sink.open()
+		if err != nil {
+			closeSinkWriters(writers)
+			return nil, fmt.Errorf("sink %q: %w", sink.Name, err)
+		}
+		writers = append(writers, w)
+	}
+	config.Writers = writers
+
+	return config, nil
+}
+
+func (fc *FileConfig) buildFilter() (*SensitiveDataFilter, error) {
+	var filter *SensitiveDataFilter
+	switch fc.FilterLevel {
+	case "", "none":
+	case "basic":
+		filter = NewBasicSensitiveDataFilter()
+	case "full":
+		filter = NewSensitiveDataFilter()
+	default:
+		return nil, fmt.Errorf("%w: %s (must be 'none', 'basic', or 'full')", ErrInvalidFilterLevel, fc.FilterLevel)
+	}
+
+	if len(fc.FilterPatterns) > 0 {
+		if filter == nil {
+			filter = NewEmptySensitiveDataFilter()
+		}
+		if err := filter.AddPatterns(fc.FilterPatterns...); err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+func (fc *FileConfig) securityConfig(filter *SensitiveDataFilter) *SecurityConfig {
+	secConfig := DefaultSecurityConfig()
+	if fc.MaxMessageSize > 0 {
+		secConfig.MaxMessageSize = fc.MaxMessageSize
+	}
+	secConfig.SensitiveFilter = filter
+	return secConfig
+}
+
+func (s SinkConfig) fileWriterConfig() FileWriterConfig {
+	return FileWriterConfig{
+		MaxSizeMB:  s.MaxSizeMB,
+		MaxAge:     time.Duration(s.MaxAge),
+		MaxBackups: s.MaxBackups,
+		Compress:   s.Compress,
+	}
+}
+
+func (s SinkConfig) open() (io.Writer, error) {
+	switch s.Type {
+	case sinkTypeStdout:
+		return os.Stdout, nil
+	case sinkTypeStderr:
+		return os.Stderr, nil
+	case sinkTypeFile:
+		return NewFileWriter(s.Path, s.fileWriterConfig())
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSinkType, s.Type)
+	}
+}
+
+// sameTarget reports whether two sink specs write to the same destination,
+// meaning the existing writer can be kept and only its limits updated.
+func (s SinkConfig) sameTarget(other SinkConfig) bool {
+	if s.Type != other.Type {
+		return false
+	}
+	if s.Type != sinkTypeFile {
+		return true
+	}
+	a, errA := validateAndSecurePath(s.Path)
+	b, errB := validateAndSecurePath(other.Path)
+	return errA == nil && errB == nil && a == b
+}
+
+func closeSinkWriters(writers []io.Writer) {
+	for _, w := range writers {
+		if w == os.Stdout || w == os.Stderr {
+			continue
+		}
+		if closer, ok := w.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+func parseLevelName(name string) (LogLevel, error) {
+	if strings.TrimSpace(name) == "" {
+		return LevelInfo, nil
+	}
+	return ParseLevel(name)
+}
+
+func parseFormatName(name string) (LogFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("%w: %q", ErrInvalidFormat, name)
+	}
+}
+
+// ConfigWatcherConfig controls how a ConfigWatcher polls its file.
+type ConfigWatcherConfig struct {
+	// Interval between modification checks (defaults to DefaultConfigPollInterval)
+	Interval time.Duration
+	// OnError is called when a changed file cannot be applied; the previous
+	// configuration stays in effect.
+	OnError func(error)
+	// OnReload is called after a new configuration has been applied.
+	OnReload func(*FileConfig)
+}
+
+type managedSink struct {
+	spec   SinkConfig
+	writer io.Writer
+}
+
+// ConfigWatcher polls a configuration file and applies level, filter,
+// sink and rotation changes to a live Logger.
+type ConfigWatcher struct {
+	logger   *Logger
+	path     string
+	interval time.Duration
+	onError  func(error)
+	onReload func(*FileConfig)
+
+	mu      sync.Mutex
+	sinks   map[string]*managedSink
+	modTime time.Time
+	size    int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewConfigWatcher loads path, applies it to logger and starts polling for
+// changes. Writers configured on the logger outside the file are left alone.
+func NewConfigWatcher(logger *Logger, path string, config ConfigWatcherConfig) (*ConfigWatcher, error) {
+	if logger == nil {
+		return nil, ErrNilLogger
+	}
+
+	cw, err := newConfigWatcher(logger, path, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cw.Reload(); err != nil {
+		cw.cancel()
+		return nil, err
+	}
+
+	cw.start()
+	return cw, nil
+}
+
+// NewWithConfigFile creates a Logger from a configuration file together with
+// a ConfigWatcher that owns the file's sinks. Closing the watcher stops
+// reloading; closing the logger closes the sinks.
+func NewWithConfigFile(path string, config ConfigWatcherConfig) (*Logger, *ConfigWatcher, error) {
+	cw, err := newConfigWatcher(nil, path, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info, statErr := os.Stat(cw.path); statErr == nil {
+		cw.modTime = info.ModTime()
+		cw.size = info.Size()
+	}
+
+	fc, err := LoadConfigFile(cw.path)
+	if err != nil {
+		cw.cancel()
+		return nil, nil, err
+	}
+
+	lc, err := fc.LoggerConfig()
+	if err != nil {
+		cw.cancel()
+		return nil, nil, err
+	}
+
+	logger, err := New(lc)
+	if err != nil {
+		cw.cancel()
+		closeSinkWriters(lc.Writers)
+		return nil, nil, err
+	}
+
+	cw.logger = logger
+	for i, spec := range fc.Sinks {
+		cw.sinks[spec.Name] = &managedSink{spec: spec, writer: lc.Writers[i]}
+	}
+
+	cw.start()
+	return logger, cw, nil
+}
+
+func newConfigWatcher(logger *Logger, path string, config ConfigWatcherConfig) (*ConfigWatcher, error) {
+	securePath, err := validateAndSecurePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = DefaultConfigPollInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ConfigWatcher{
+		logger:   logger,
+		path:     securePath,
+		interval: config.Interval,
+		onError:  config.OnError,
+		onReload: config.OnReload,
+		sinks:    make(map[string]*managedSink),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (cw *ConfigWatcher) start() {
+	cw.wg.Add(1)
+	go cw.pollRoutine()
+}
+
+// Reload reads the file and applies it immediately, regardless of whether
+// it changed. On error the previous configuration is kept.
+func (cw *ConfigWatcher) Reload() error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	info, err := os.Stat(cw.path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+	}
+	cw.modTime = info.ModTime()
+	cw.size = info.Size()
+
+	fc, err := LoadConfigFile(cw.path)
+	if err != nil {
+		return err
+	}
+
+	if err := cw.apply(fc); err != nil {
+		return err
+	}
+
+	if cw.onReload != nil {
+		cw.onReload(fc)
+	}
+	return nil
+}
+
+// apply builds the new sink set before touching the logger so that a
+// failure leaves the running configuration untouched.
+func (cw *ConfigWatcher) apply(fc *FileConfig) error {
+	level, _ := parseLevelName(fc.Level)
+	filter, _ := fc.buildFilter()
+
+	next := make(map[string]*managedSink, len(fc.Sinks))
+	var opened, added, removed []io.Writer
+	type limitUpdate struct {
+		fw     *FileWriter
+		config FileWriterConfig
+	}
+	var updates []limitUpdate
+
+	for _, spec := range fc.Sinks {
+		if cur, ok := cw.sinks[spec.Name]; ok && cur.spec.sameTarget(spec) {
+			next[spec.Name] = &managedSink{spec: spec, writer: cur.writer}
+			if fw, ok := cur.writer.(*FileWriter); ok {
+				updates = append(updates, limitUpdate{fw: fw, config: spec.fileWriterConfig()})
+			}
+			continue
+		}
+
+		w, err := spec.open()
+		if err != nil {
+			closeSinkWriters(opened)
+			return fmt.Errorf("sink %q: %w", spec.Name, err)
+		}
+		opened = append(opened, w)
+		added = append(added, w)
+		next[spec.Name] = &managedSink{spec: spec, writer: w}
+	}
+
+	for name, cur := range cw.sinks {
+		if kept, ok := next[name]; ok && kept.writer == cur.writer {
+			continue
+		}
+		removed = append(removed, cur.writer)
+	}
+
+	if err := cw.logger.swapConfig(level, fc.securityConfig(filter), removed, added); err != nil {
+		closeSinkWriters(opened)
+		return err
+	}
+
+	for _, u := range updates {
+		u.fw.setLimits(u.config)
+	}
+
+	// Removed writers are no longer reachable from the logger, and
+	// swapConfig waits for in-flight writes, so closing them is safe.
+	closeSinkWriters(removed)
+	cw.sinks = next
+	return nil
+}
+
+func (cw *ConfigWatcher) pollRoutine() {
+	defer cw.wg.Done()
+
+	ticker := time.NewTicker(cw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cw.ctx.Done():
+			return
+		case <-ticker.C:
+			if !cw.changed() {
+				continue
+			}
+			if err := cw.Reload(); err != nil && cw.onError != nil {
+				cw.onError(err)
+			}
+		}
+	}
+}
+
+func (cw *ConfigWatcher) changed() bool {
+	info, err := os.Stat(cw.path)
+	if err != nil {
+		return false
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return !info.ModTime().Equal(cw.modTime) || info.Size() != cw.size
+}
+
+// Close stops polling. Sinks opened by the watcher stay attached to the
+// logger and are closed by Logger.Close.
+func (cw *ConfigWatcher) Close() error {
+	cw.cancel()
+	cw.wg.Wait()
+	return nil
+}
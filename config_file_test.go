@@ -0,0 +1,312 @@
+package dd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// CONFIG FILE PARSING TESTS
+// ============================================================================
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name: "valid config",
+			data: `{"level":"debug","format":"json","filter_level":"basic",
+				"sinks":[{"name":"console","type":"stdout"},
+				{"name":"app","type":"file","path":"logs/app.log","max_size_mb":5,"max_age":"24h"}]}`,
+		},
+		{
+			name:    "invalid level",
+			data:    `{"level":"loud"}`,
+			wantErr: ErrInvalidLevel,
+		},
+		{
+			name:    "invalid format",
+			data:    `{"format":"xml"}`,
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "invalid filter level",
+			data:    `{"filter_level":"paranoid"}`,
+			wantErr: ErrInvalidFilterLevel,
+		},
+		{
+			name:    "invalid filter pattern",
+			data:    `{"filter_patterns":["[invalid"]}`,
+			wantErr: ErrInvalidPattern,
+		},
+		{
+			name:    "unknown sink type",
+			data:    `{"sinks":[{"name":"x","type":"carrier-pigeon"}]}`,
+			wantErr: ErrUnknownSinkType,
+		},
+		{
+			name:    "duplicate sink name",
+			data:    `{"sinks":[{"name":"x","type":"stdout"},{"name":"x","type":"stderr"}]}`,
+			wantErr: ErrInvalidConfigFile,
+		},
+		{
+			name:    "unknown field",
+			data:    `{"levle":"info"}`,
+			wantErr: ErrInvalidConfigFile,
+		},
+		{
+			name:    "malformed json",
+			data:    `{"level":`,
+			wantErr: ErrInvalidConfigFile,
+		},
+		{
+			name:    "path traversal in sink",
+			data:    `{"sinks":[{"name":"x","type":"file","path":"../../etc/passwd"}]}`,
+			wantErr: ErrPathTraversal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, err := ParseConfig([]byte(tt.data))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ParseConfig() unexpected error: %v", err)
+				}
+				if fc == nil {
+					t.Fatal("ParseConfig() returned nil config")
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseConfig() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileConfigLoggerConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "app.log")
+
+	fc, err := ParseConfig([]byte(`{"level":"warn","format":"json","filter_level":"basic",
+		"sinks":[{"name":"app","type":"file","path":"` + filepath.ToSlash(logFile) + `"}]}`))
+	if err != nil {
+		t.Fatalf("ParseConfig() error: %v", err)
+	}
+
+	config, err := fc.LoggerConfig()
+	if err != nil {
+		t.Fatalf("LoggerConfig() error: %v", err)
+	}
+
+	if config.Level != LevelWarn {
+		t.Errorf("Expected level Warn, got %v", config.Level)
+	}
+	if config.Format != FormatJSON {
+		t.Errorf("Expected JSON format, got %v", config.Format)
+	}
+	if config.SecurityConfig.SensitiveFilter == nil {
+		t.Error("Expected sensitive filter to be configured")
+	}
+	if len(config.Writers) != 1 {
+		t.Fatalf("Expected 1 writer, got %d", len(config.Writers))
+	}
+
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	logger.Warn("password=hunter2")
+	logger.Close()
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("Password should be filtered, got: %s", data)
+	}
+}
+
+// ============================================================================
+// CONFIG WATCHER TESTS
+// ============================================================================
+
+func writeConfigFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestConfigWatcherReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "dd.json")
+	logA := filepath.ToSlash(filepath.Join(tmpDir, "a.log"))
+	logB := filepath.ToSlash(filepath.Join(tmpDir, "b.log"))
+
+	writeConfigFile(t, cfgPath, `{"level":"info","sinks":[{"name":"a","type":"file","path":"`+logA+`"}]}`)
+
+	logger, watcher, err := NewWithConfigFile(cfgPath, ConfigWatcherConfig{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewWithConfigFile() error: %v", err)
+	}
+	defer logger.Close()
+	defer watcher.Close()
+
+	logger.Debug("hidden debug")
+	logger.Info("first")
+
+	writeConfigFile(t, cfgPath, `{"level":"debug","filter_patterns":["secret-[0-9]+"],
+		"sinks":[{"name":"b","type":"file","path":"`+logB+`"}]}`)
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+
+	if logger.GetLevel() != LevelDebug {
+		t.Errorf("Expected level Debug after reload, got %v", logger.GetLevel())
+	}
+
+	logger.Debug("second secret-42")
+
+	dataA, _ := os.ReadFile(logA)
+	dataB, _ := os.ReadFile(logB)
+
+	if !strings.Contains(string(dataA), "first") || strings.Contains(string(dataA), "second") {
+		t.Errorf("Sink a should contain only the first record, got: %s", dataA)
+	}
+	if strings.Contains(string(dataA), "hidden debug") {
+		t.Errorf("Debug record should have been filtered before reload, got: %s", dataA)
+	}
+	if !strings.Contains(string(dataB), "second [REDACTED]") {
+		t.Errorf("Sink b should contain the filtered second record, got: %s", dataB)
+	}
+}
+
+func TestConfigWatcherInvalidConfigKeepsOld(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "dd.json")
+	writeConfigFile(t, cfgPath, `{"level":"warn"}`)
+
+	var buf bytes.Buffer
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&buf}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	var errCount atomic.Int32
+	watcher, err := NewConfigWatcher(logger, cfgPath, ConfigWatcherConfig{
+		Interval: 10 * time.Millisecond,
+		OnError:  func(error) { errCount.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("NewConfigWatcher() error: %v", err)
+	}
+	defer watcher.Close()
+
+	if logger.GetLevel() != LevelWarn {
+		t.Fatalf("Expected level Warn, got %v", logger.GetLevel())
+	}
+
+	writeConfigFile(t, cfgPath, `{"level":"debug","sinks":[{"name":"x","type":"nope"}]}`)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for errCount.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if errCount.Load() == 0 {
+		t.Fatal("Expected OnError to be called for invalid config")
+	}
+	if logger.GetLevel() != LevelWarn {
+		t.Errorf("Invalid config should not change level, got %v", logger.GetLevel())
+	}
+
+	logger.Warn("still logging")
+	if !strings.Contains(buf.String(), "still logging") {
+		t.Errorf("Existing writer should be kept, got: %s", buf.String())
+	}
+}
+
+func TestConfigWatcherRotationLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "dd.json")
+	logPath := filepath.ToSlash(filepath.Join(tmpDir, "app.log"))
+
+	writeConfigFile(t, cfgPath, `{"sinks":[{"name":"app","type":"file","path":"`+logPath+`","max_size_mb":1}]}`)
+
+	logger, watcher, err := NewWithConfigFile(cfgPath, ConfigWatcherConfig{Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewWithConfigFile() error: %v", err)
+	}
+	defer logger.Close()
+	defer watcher.Close()
+
+	fw := watcher.sinks["app"].writer.(*FileWriter)
+
+	writeConfigFile(t, cfgPath, `{"sinks":[{"name":"app","type":"file","path":"`+logPath+`","max_size_mb":7,"max_backups":3}]}`)
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+
+	if watcher.sinks["app"].writer != fw {
+		t.Error("Sink with unchanged path should keep its writer")
+	}
+
+	fw.mu.Lock()
+	maxSize, maxBackups := fw.maxSize, fw.maxBackups
+	fw.mu.Unlock()
+
+	if maxSize != 7*1024*1024 {
+		t.Errorf("Expected maxSize 7MB, got %d", maxSize)
+	}
+	if maxBackups != 3 {
+		t.Errorf("Expected maxBackups 3, got %d", maxBackups)
+	}
+
+	// Without max_age the writer runs cleanup with the default retention
+	fw.mu.Lock()
+	maxAge, cleaning := fw.maxAge, fw.cleaning
+	fw.mu.Unlock()
+	if maxAge != DefaultMaxAge || !cleaning {
+		t.Errorf("Expected cleanup with the default maxAge, got maxAge %v, running %v", maxAge, cleaning)
+	}
+
+	writeConfigFile(t, cfgPath, `{"sinks":[{"name":"app","type":"file","path":"`+logPath+`","max_size_mb":7,"max_backups":3,"max_age":"24h"}]}`)
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+
+	fw.mu.Lock()
+	maxAge, cleaning = fw.maxAge, fw.cleaning
+	fw.mu.Unlock()
+	if maxAge != 24*time.Hour || !cleaning {
+		t.Errorf("Expected cleanup to start with maxAge 24h, got maxAge %v, running %v", maxAge, cleaning)
+	}
+
+	// A writer without a cleanup routine starts one when limits set MaxAge
+	ctx, cancel := context.WithCancel(context.Background())
+	idle := &FileWriter{path: logPath + ".idle", ctx: ctx, cancel: cancel}
+	idle.setLimits(FileWriterConfig{MaxAge: time.Hour})
+	idle.mu.Lock()
+	cleaning = idle.cleaning
+	idle.mu.Unlock()
+	if !cleaning {
+		t.Error("setLimits should start the cleanup routine")
+	}
+	_ = idle.Close()
+}
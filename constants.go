@@ -43,6 +43,47 @@ const (
 	RetryAttempts        = 3                     // File operation retry attempts
 	RetryDelay           = 10 * time.Millisecond // Retry delay
 	VerifyBufferSize     = 1024                  // Buffer size for verification
+	MaxRedactDepth       = 10                    // Maximum nesting walked when redacting field values
+	MaxRedactNodes       = 1000                  // Maximum values walked per redacted field
+
+	// Entropy detection constants
+	DefaultEntropyMinLength       = 20  // Shortest token checked for entropy
+	DefaultEntropyBase64Threshold = 4.0 // Bits per character flagging a base64-like token
+	DefaultEntropyHexThreshold    = 3.0 // Bits per character flagging a hex token
+
+	// Writer failure handling constants
+	DefaultWriterFailureThreshold = 5                // Consecutive failures before a writer is disabled
+	DefaultWriterRetryInterval    = 30 * time.Second // Time a disabled writer is skipped before retrying
+
+	// Network writer constants
+	DefaultDialTimeout     = 5 * time.Second        // Network dial timeout
+	DefaultNetWriteTimeout = 5 * time.Second        // Network write deadline
+	DefaultMinBackoff      = 100 * time.Millisecond // Initial reconnect delay
+	DefaultMaxBackoff      = 30 * time.Second       // Maximum reconnect delay
+	DefaultNetBufferSize   = 8 * 1024 * 1024        // Outage buffer size (8MB)
+
+	// HTTP batch writer constants
+	DefaultHTTPTimeout       = 10 * time.Second // Per-request timeout
+	DefaultHTTPBatchRecords  = 500              // Records per batch
+	DefaultHTTPBatchBytes    = 1024 * 1024      // Bytes per batch (1MB)
+	DefaultHTTPFlushInterval = time.Second      // Maximum time a partial batch waits
+	DefaultHTTPQueueSize     = 16               // Full batches waiting for the sender
+	DefaultHTTPMaxRetries    = 3                // Retries for 5xx, 429 and transport errors
+
+	// Spool writer constants
+	DefaultSpoolSegmentSize    = 16 * 1024 * 1024 // Spool segment rotation size (16MB)
+	DefaultSpoolMaxSegments    = 64               // Spool segments kept before writes are rejected
+	DefaultSpoolCommitInterval = time.Second      // Maximum delay before the replay position is persisted
+
+	// Journald writer constants
+	DefaultJournaldSocket       = "/run/systemd/journal/socket" // journald native protocol socket
+	DefaultJournaldDatagramSize = 128 * 1024                    // Entries above this size are sent via memfd
+
+	// Flight recorder constants
+	DefaultRingBufferSize = 1000 // Records kept by a RingBufferWriter
+
+	// Configuration file constants
+	DefaultConfigPollInterval = 2 * time.Second // Default config file modification check interval
 
 	// Default file paths
 	DefaultLogFile = "logs/app.log" // Default log file path
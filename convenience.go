@@ -27,7 +27,7 @@ type Options struct {
 
 func NewWithOptions(opts Options) (*Logger, error) {
 	// Validate and normalize options
-	if opts.Level < LevelDebug || opts.Level > LevelFatal {
+	if !opts.Level.IsValid() {
 		opts.Level = LevelDebug
 	}
 	if opts.Format != FormatText && opts.Format != FormatJSON {
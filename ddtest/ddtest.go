@@ -0,0 +1,296 @@
+// Package ddtest provides an observing Logger for tests. Instead of parsing
+// formatted output from a buffer, tests inspect the structured entries the
+// logger produced:
+//
+//	logger, logs := ddtest.New(t)
+//	svc := NewService(logger)
+//	svc.Login("alice")
+//
+//	logs.AssertLogged(dd.LevelInfo, "user logged in")
+//	if got := logs.FilterField(dd.String("user", "alice")).Len(); got != 1 {
+//		t.Errorf("expected one entry for alice, got %d", got)
+//	}
+//
+// Entries are also echoed to t.Log, so they are shown only for failing
+// tests or with go test -v.
+package ddtest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cybergodev/dd"
+)
+
+// Observer records the entries written to a Logger. It implements
+// dd.EntryWriter and is safe for concurrent use.
+type Observer struct {
+	tb      testing.TB
+	done    atomic.Bool
+	mu      sync.Mutex
+	entries []dd.Entry
+}
+
+// New returns a Logger at LevelTrace whose entries are recorded by the
+// returned Observer. The logger is closed when the test finishes.
+func New(tb testing.TB) (*dd.Logger, *Observer) {
+	tb.Helper()
+	config := dd.DefaultConfig()
+	config.Level = dd.LevelTrace
+	return NewWithConfig(tb, config)
+}
+
+// NewWithConfig is like New but uses config, whose writers are replaced by
+// the Observer. The security configuration still applies, so tests can
+// check what the filter lets through.
+func NewWithConfig(tb testing.TB, config *dd.LoggerConfig) (*dd.Logger, *Observer) {
+	tb.Helper()
+	observer := NewObserver(tb)
+
+	config = config.Clone()
+	config.Writers = []io.Writer{observer}
+	logger, err := dd.New(config)
+	if err != nil {
+		tb.Fatalf("ddtest: failed to create logger: %v", err)
+	}
+	tb.Cleanup(func() { _ = logger.Close() })
+	return logger, observer
+}
+
+// NewObserver creates an Observer to attach to a Logger. Entries are echoed
+// to tb.Log until the test finishes. tb may be nil to disable echoing, but
+// the Assert methods need it.
+func NewObserver(tb testing.TB) *Observer {
+	o := &Observer{tb: tb}
+	if tb != nil {
+		tb.Cleanup(func() { o.done.Store(true) })
+	}
+	return o
+}
+
+// WriteEntry records entry.
+func (o *Observer) WriteEntry(entry *dd.Entry) error {
+	captured := *entry
+	captured.Fields = append([]dd.Field(nil), entry.Fields...)
+
+	o.mu.Lock()
+	o.entries = append(o.entries, captured)
+	o.mu.Unlock()
+
+	o.echo(formatEntry(captured))
+	return nil
+}
+
+// Write receives records that are not available as entries, such as
+// flight recorder dumps or writes through a MultiWriter. They are echoed
+// but not recorded.
+func (o *Observer) Write(p []byte) (int, error) {
+	o.echo(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (o *Observer) echo(line string) {
+	if o.tb != nil && !o.done.Load() {
+		o.tb.Log(line)
+	}
+}
+
+func formatEntry(e dd.Entry) string {
+	var sb strings.Builder
+	sb.WriteString(e.Level.String())
+	sb.WriteByte(' ')
+	sb.WriteString(e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+	}
+	if e.File != "" {
+		fmt.Fprintf(&sb, " (%s:%d)", e.File, e.Line)
+	}
+	return sb.String()
+}
+
+// All returns a copy of every recorded entry, oldest first.
+func (o *Observer) All() Entries {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append(Entries(nil), o.entries...)
+}
+
+// TakeAll returns every recorded entry and clears the Observer.
+func (o *Observer) TakeAll() Entries {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entries := Entries(o.entries)
+	o.entries = nil
+	return entries
+}
+
+// Len returns the number of recorded entries.
+func (o *Observer) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// Reset discards every recorded entry.
+func (o *Observer) Reset() {
+	o.mu.Lock()
+	o.entries = nil
+	o.mu.Unlock()
+}
+
+// FilterLevel returns the entries logged at exactly level.
+func (o *Observer) FilterLevel(level dd.LogLevel) Entries { return o.All().FilterLevel(level) }
+
+// FilterMessage returns the entries whose message equals msg.
+func (o *Observer) FilterMessage(msg string) Entries { return o.All().FilterMessage(msg) }
+
+// FilterMessageSnippet returns the entries whose message contains snippet.
+func (o *Observer) FilterMessageSnippet(snippet string) Entries {
+	return o.All().FilterMessageSnippet(snippet)
+}
+
+// FilterField returns the entries that have a field equal to field.
+func (o *Observer) FilterField(field dd.Field) Entries { return o.All().FilterField(field) }
+
+// FilterFieldKey returns the entries that have a field named key.
+func (o *Observer) FilterFieldKey(key string) Entries { return o.All().FilterFieldKey(key) }
+
+// Entries is a list of recorded entries. Filters return a new list, so
+// they can be chained.
+type Entries []dd.Entry
+
+// Len returns the number of entries.
+func (es Entries) Len() int { return len(es) }
+
+// Messages returns the message of each entry.
+func (es Entries) Messages() []string {
+	messages := make([]string, len(es))
+	for i, e := range es {
+		messages[i] = e.Message
+	}
+	return messages
+}
+
+// Filter returns the entries for which keep returns true.
+func (es Entries) Filter(keep func(dd.Entry) bool) Entries {
+	var filtered Entries
+	for _, e := range es {
+		if keep(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel returns the entries logged at exactly level.
+func (es Entries) FilterLevel(level dd.LogLevel) Entries {
+	return es.Filter(func(e dd.Entry) bool { return e.Level == level })
+}
+
+// FilterMessage returns the entries whose message equals msg.
+func (es Entries) FilterMessage(msg string) Entries {
+	return es.Filter(func(e dd.Entry) bool { return e.Message == msg })
+}
+
+// FilterMessageSnippet returns the entries whose message contains snippet.
+func (es Entries) FilterMessageSnippet(snippet string) Entries {
+	return es.Filter(func(e dd.Entry) bool { return strings.Contains(e.Message, snippet) })
+}
+
+// FilterField returns the entries that have a field with the same key and
+// a deeply equal value.
+func (es Entries) FilterField(field dd.Field) Entries {
+	return es.Filter(func(e dd.Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == field.Key && reflect.DeepEqual(f.Value, field.Value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterFieldKey returns the entries that have a field named key.
+func (es Entries) FilterFieldKey(key string) Entries {
+	return es.Filter(func(e dd.Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// AssertLogged reports a test error unless an entry with level and msg was
+// recorded.
+func (o *Observer) AssertLogged(level dd.LogLevel, msg string) bool {
+	o.tb.Helper()
+	if o.FilterLevel(level).FilterMessage(msg).Len() > 0 {
+		return true
+	}
+	o.tb.Errorf("ddtest: expected %s entry %q, recorded:\n%s", level, msg, o.describe())
+	return false
+}
+
+// AssertNotLogged reports a test error if an entry with level and msg was
+// recorded.
+func (o *Observer) AssertNotLogged(level dd.LogLevel, msg string) bool {
+	o.tb.Helper()
+	if o.FilterLevel(level).FilterMessage(msg).Len() == 0 {
+		return true
+	}
+	o.tb.Errorf("ddtest: unexpected %s entry %q", level, msg)
+	return false
+}
+
+// AssertField reports a test error unless an entry with message msg has a
+// field equal to field.
+func (o *Observer) AssertField(msg string, field dd.Field) bool {
+	o.tb.Helper()
+	if o.FilterMessage(msg).FilterField(field).Len() > 0 {
+		return true
+	}
+	o.tb.Errorf("ddtest: expected entry %q with field %s=%v, recorded:\n%s", msg, field.Key, field.Value, o.describe())
+	return false
+}
+
+// AssertCount reports a test error unless exactly n entries were recorded.
+func (o *Observer) AssertCount(n int) bool {
+	o.tb.Helper()
+	if got := o.Len(); got != n {
+		o.tb.Errorf("ddtest: expected %d entries, got %d:\n%s", n, got, o.describe())
+		return false
+	}
+	return true
+}
+
+// AssertNoErrors reports a test error if any ERROR or FATAL entry was
+// recorded.
+func (o *Observer) AssertNoErrors() bool {
+	o.tb.Helper()
+	errs := o.All().Filter(func(e dd.Entry) bool { return e.Level >= dd.LevelError })
+	if errs.Len() == 0 {
+		return true
+	}
+	o.tb.Errorf("ddtest: expected no errors, got %d: %q", errs.Len(), errs.Messages())
+	return false
+}
+
+func (o *Observer) describe() string {
+	entries := o.All()
+	if len(entries) == 0 {
+		return "  (none)"
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "  " + formatEntry(e)
+	}
+	return strings.Join(lines, "\n")
+}
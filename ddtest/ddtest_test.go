@@ -0,0 +1,131 @@
+package ddtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cybergodev/dd"
+)
+
+// fakeTB records failures instead of failing the enclosing test
+type fakeTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+	logs   []string
+}
+
+func (f *fakeTB) Helper()           {}
+func (f *fakeTB) Cleanup(fn func()) {}
+func (f *fakeTB) Log(args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestObserverCapturesEntries(t *testing.T) {
+	logger, logs := New(t)
+
+	logger.Debug("starting")
+	logger.InfoWith("user logged in", dd.String("user", "alice"), dd.Int("attempt", 2))
+	logger.Warnf("disk %d%% full", 91)
+
+	all := logs.All()
+	if all.Len() != 3 {
+		t.Fatalf("Expected 3 entries, got %d", all.Len())
+	}
+
+	login := all[1]
+	if login.Level != dd.LevelInfo || login.Message != "user logged in" || len(login.Fields) != 2 {
+		t.Errorf("Unexpected entry: %+v", login)
+	}
+	if !strings.HasSuffix(login.File, "ddtest_test.go") || login.Line == 0 {
+		t.Errorf("Caller should point at the test, got %s:%d", login.File, login.Line)
+	}
+
+	logs.AssertLogged(dd.LevelWarn, "disk 91% full")
+	logs.AssertField("user logged in", dd.String("user", "alice"))
+	logs.AssertNotLogged(dd.LevelError, "starting")
+	logs.AssertNoErrors()
+	logs.AssertCount(3)
+}
+
+func TestEntriesFilters(t *testing.T) {
+	logger, logs := New(t)
+
+	logger.InfoWith("request", dd.String("path", "/a"), dd.Int("status", 200))
+	logger.InfoWith("request", dd.String("path", "/b"), dd.Int("status", 500))
+	logger.ErrorWith("request failed", dd.String("path", "/b"))
+
+	if got := logs.FilterLevel(dd.LevelInfo).Len(); got != 2 {
+		t.Errorf("FilterLevel: expected 2, got %d", got)
+	}
+	if got := logs.FilterMessage("request").FilterField(dd.Int("status", 500)).Len(); got != 1 {
+		t.Errorf("Chained filters: expected 1, got %d", got)
+	}
+	if got := logs.FilterField(dd.String("path", "/b")).Messages(); len(got) != 2 || got[1] != "request failed" {
+		t.Errorf("FilterField: unexpected messages %q", got)
+	}
+	if got := logs.FilterFieldKey("status").Len(); got != 2 {
+		t.Errorf("FilterFieldKey: expected 2, got %d", got)
+	}
+	if got := logs.FilterMessageSnippet("fail").Len(); got != 1 {
+		t.Errorf("FilterMessageSnippet: expected 1, got %d", got)
+	}
+
+	if taken := logs.TakeAll(); taken.Len() != 3 || logs.Len() != 0 {
+		t.Errorf("TakeAll should return and clear entries, got %d with %d left", taken.Len(), logs.Len())
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	tb := &fakeTB{}
+	logger, logs := New(tb)
+
+	logger.Error("boom")
+
+	if logs.AssertLogged(dd.LevelInfo, "missing") {
+		t.Error("AssertLogged should fail for a missing entry")
+	}
+	if logs.AssertNotLogged(dd.LevelError, "boom") {
+		t.Error("AssertNotLogged should fail for a recorded entry")
+	}
+	if logs.AssertField("boom", dd.String("k", "v")) {
+		t.Error("AssertField should fail for a missing field")
+	}
+	if logs.AssertCount(2) {
+		t.Error("AssertCount should fail for a wrong count")
+	}
+	if logs.AssertNoErrors() {
+		t.Error("AssertNoErrors should fail when an error was logged")
+	}
+
+	if len(tb.errors) != 5 {
+		t.Fatalf("Expected 5 reported failures, got %d: %q", len(tb.errors), tb.errors)
+	}
+	if !strings.Contains(tb.errors[0], "ERROR boom") {
+		t.Errorf("Failure should list recorded entries, got %q", tb.errors[0])
+	}
+	if len(tb.logs) != 1 || !strings.HasPrefix(tb.logs[0], "ERROR boom") {
+		t.Errorf("Entries should be echoed to tb.Log, got %q", tb.logs)
+	}
+}
+
+func TestObserverAppliesSecurity(t *testing.T) {
+	config := dd.DefaultConfig()
+	config.SecurityConfig = &dd.SecurityConfig{SensitiveFilter: dd.NewBasicSensitiveDataFilter()}
+	logger, logs := NewWithConfig(t, config)
+
+	logger.Info("login password=hunter22")
+
+	if msg := logs.All()[0].Message; strings.Contains(msg, "hunter22") {
+		t.Errorf("Observed message should be filtered, got %q", msg)
+	}
+}
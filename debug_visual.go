@@ -184,6 +184,7 @@ type TypeConverter struct {
 	visited  map[uintptr]bool // Track visited pointers to handle circular references
 	depth    int              // Current recursion depth
 	maxDepth int              // Maximum recursion depth
+	redact   *nestedRedaction // Set when converting field values for redaction
 }
 
 // TypeConverter pool for performance optimization
@@ -200,6 +201,8 @@ var typeConverterPool = sync.Pool{
 func getTypeConverter() *TypeConverter {
 	tc := typeConverterPool.Get().(*TypeConverter)
 	tc.depth = 0
+	tc.redact = nil
+	tc.maxDepth = 10
 	// Clear the visited map for reuse
 	for k := range tc.visited {
 		delete(tc.visited, k)
@@ -268,8 +271,18 @@ func (tc *TypeConverter) convertReflectValue(val reflect.Value) any {
 		return tc.convertReflectValue(val.Elem())
 	}
 
+	if tc.redact != nil && !tc.redact.visit() {
+		return redactedTruncated
+	}
+
 	switch val.Kind() {
-	case reflect.String, reflect.Bool,
+	case reflect.String:
+		if tc.redact != nil {
+			return tc.redact.filterString(val)
+		}
+		return val.Interface()
+
+	case reflect.Bool,
 		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
 		reflect.Float32, reflect.Float64:
@@ -284,14 +297,24 @@ func (tc *TypeConverter) convertReflectValue(val reflect.Value) any {
 	case reflect.UnsafePointer:
 		return fmt.Sprintf("<unsafe.Pointer:0x%x>", val.Pointer())
 
-	case reflect.Slice, reflect.Array:
-		return tc.convertSliceOrArray(val)
-
-	case reflect.Map:
-		return tc.convertMap(val)
-
-	case reflect.Struct:
-		return tc.convertStruct(val)
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		if tc.depth > tc.maxDepth {
+			if tc.redact != nil {
+				tc.redact.changed = true
+			}
+			return fmt.Sprintf("<max_depth_exceeded:%d>", tc.maxDepth)
+		}
+		tc.depth++
+		defer func() { tc.depth-- }()
+
+		switch val.Kind() {
+		case reflect.Map:
+			return tc.convertMap(val)
+		case reflect.Struct:
+			return tc.convertStruct(val)
+		default:
+			return tc.convertSliceOrArray(val)
+		}
 
 	case reflect.Complex64, reflect.Complex128:
 		return fmt.Sprintf("%v", val.Interface())
@@ -354,6 +377,10 @@ func (tc *TypeConverter) convertMap(val reflect.Value) any {
 
 	for _, key := range keys {
 		keyStr := tc.convertKeyToString(key)
+		if tc.redact != nil && tc.redact.filter.IsSensitiveKey(keyStr) {
+			result[keyStr] = tc.redact.maskValue(keyStr, val.MapIndex(key))
+			continue
+		}
 		value := tc.convertReflectValue(val.MapIndex(key))
 		result[keyStr] = value
 	}
@@ -441,6 +468,19 @@ func (tc *TypeConverter) convertStruct(val reflect.Value) any {
 			}
 		}
 
+		// Honour dd:"-" and dd:"redact", and mask sensitive field names
+		// when redacting
+		switch ddTag, _, _ := strings.Cut(fieldType.Tag.Get("dd"), ","); {
+		case ddTag == "-":
+			continue
+		case ddTag == "redact":
+			result[fieldName] = tc.redact.maskValue(fieldName, field)
+			continue
+		case tc.redact != nil && (tc.redact.filter.IsSensitiveKey(fieldName) || tc.redact.filter.IsSensitiveKey(fieldType.Name)):
+			result[fieldName] = tc.redact.maskValue(fieldName, field)
+			continue
+		}
+
 		// Convert field value
 		fieldValue := tc.convertReflectValue(field)
 		result[fieldName] = fieldValue
@@ -0,0 +1,171 @@
+package dd
+
+import (
+	"net"
+	"strings"
+)
+
+// DetectorCategory groups built-in detectors so they can be enabled
+// together. Categories combine with |.
+type DetectorCategory uint8
+
+const (
+	// CategoryPII covers personal data: SSNs, email addresses and phone numbers
+	CategoryPII DetectorCategory = 1 << iota
+	// CategoryFinancial covers payment card numbers and IBANs
+	CategoryFinancial
+	// CategoryCredentials covers passwords, API keys, cloud and VCS tokens,
+	// private keys and credentials embedded in connection strings and URLs
+	CategoryCredentials
+	// CategoryNetwork covers IPv4 and IPv6 addresses
+	CategoryNetwork
+
+	// CategoryAll enables every built-in detector
+	CategoryAll = CategoryPII | CategoryFinancial | CategoryCredentials | CategoryNetwork
+)
+
+func (c DetectorCategory) String() string {
+	var names []string
+	for _, cat := range []struct {
+		c    DetectorCategory
+		name string
+	}{
+		{CategoryPII, "pii"},
+		{CategoryFinancial, "financial"},
+		{CategoryCredentials, "credentials"},
+		{CategoryNetwork, "network"},
+	} {
+		if c&cat.c != 0 {
+			names = append(names, cat.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// detector is an entry of the built-in catalogue. Patterns use bounded
+// repetition only; Go's RE2 engine runs them in linear time.
+type detector struct {
+	category DetectorCategory
+	full     bool // part of NewSensitiveDataFilter
+	basic    bool // part of NewBasicSensitiveDataFilter
+	rule     RedactionRule
+}
+
+var builtinDetectors = []detector{
+	// Credit card numbers (13-19 digits with optional separators)
+	{CategoryFinancial, true, true, RedactionRule{Name: "credit_card", Pattern: `\b[0-9]{4}[- ]?[0-9]{4}[- ]?[0-9]{4}[- ]?[0-9]{3,7}\b`, Validate: ValidLuhn}},
+	// SSN (strict format)
+	{CategoryPII, true, true, RedactionRule{Name: "ssn", Pattern: `\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`, Validate: ValidSSN}},
+	// Password/secret fields (length limited)
+	{CategoryCredentials, true, true, RedactionRule{Name: "password", Pattern: `(?i)(?:password|passwd|pwd|secret)[\s:=]+[^\s]{1,32}\b`}},
+	// API keys and tokens (length limited)
+	{CategoryCredentials, true, true, RedactionRule{Name: "api_key", Pattern: `(?i)(?:token|api[_-]?key|bearer)[\s:=]+[^\s]{1,128}\b`}},
+	// JWT tokens (strict three-part format)
+	{CategoryCredentials, true, false, RedactionRule{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]{10,100}\.eyJ[A-Za-z0-9_-]{10,100}\.[A-Za-z0-9_-]{10,100}\b`}},
+	// Private keys (the body cannot contain "-", so the match stops at END)
+	{CategoryCredentials, true, true, RedactionRule{Name: "private_key", Pattern: `-----BEGIN[^-]{1,20}PRIVATE\s+KEY-----[A-Za-z0-9+/=\s]+-----END[^-]{1,20}PRIVATE\s+KEY-----`}},
+	// AWS Access Keys
+	{CategoryCredentials, true, false, RedactionRule{Name: "aws_access_key", Pattern: `\bAKIA[0-9A-Z]{16}\b`}},
+	// Google API Keys
+	{CategoryCredentials, true, false, RedactionRule{Name: "google_api_key", Pattern: `\bAIza[A-Za-z0-9_-]{35}\b`}},
+	// OpenAI API Keys
+	{CategoryCredentials, true, true, RedactionRule{Name: "openai_api_key", Pattern: `\bsk-[A-Za-z0-9]{20,48}\b`}},
+	// Basic-auth credentials embedded in URLs, keeping the scheme;
+	// ahead of email so that "pass@host" is not taken for an address
+	{CategoryCredentials, false, false, RedactionRule{
+		Name:    "url_credentials",
+		Pattern: `\b(?P<scheme>[A-Za-z][A-Za-z0-9+.-]{1,20})://[^\s:/@]{1,64}:[^\s/@]{1,128}@`,
+		Mask:    TemplateMask("${scheme}://[REDACTED]@"),
+	}},
+	// Email addresses (simplified but secure)
+	{CategoryPII, true, false, RedactionRule{Name: "email", Pattern: `\b[A-Za-z0-9._%+-]{1,64}@[A-Za-z0-9.-]{1,253}\.[A-Za-z]{2,6}\b`}},
+	// IPv6 addresses, full or compressed, including "::1" and IPv4-mapped
+	// forms; ahead of ipv4 so that "::ffff:192.0.2.1" is masked as a whole.
+	// A colon after the last group is left alone, and candidates are
+	// checked with net.ParseIP.
+	{CategoryNetwork, false, false, RedactionRule{Name: "ipv6", Pattern: `(?i)(?:\b[0-9a-f]{1,4}(?:::?[0-9a-f]{1,4}){1,7}(?:::|\b)|\B::[0-9a-f]{1,4}(?:::?[0-9a-f]{1,4}){0,6}\b)(?:(?:\.[0-9]{1,3}){3})?`, Validate: validIPv6}},
+	// IPv4 addresses
+	{CategoryNetwork, true, false, RedactionRule{Name: "ipv4", Pattern: `\b(?:[0-9]{1,3}\.){3}[0-9]{1,3}\b`}},
+	// Database connection strings
+	{CategoryCredentials, true, false, RedactionRule{Name: "db_connection", Pattern: `(?i)(?:mysql|postgresql|mongodb)://[^\s]{1,200}\b`}},
+
+	// E.164 phone numbers
+	{CategoryPII, false, false, RedactionRule{Name: "phone", Pattern: `\B\+[1-9][0-9]{7,14}\b`}},
+	// IBANs, optionally grouped in fours, with a mod-97 check
+	{CategoryFinancial, false, false, RedactionRule{Name: "iban", Pattern: `\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`, Validate: ValidIBAN}},
+	// GitHub personal, OAuth, app and fine-grained tokens
+	{CategoryCredentials, false, false, RedactionRule{Name: "github_token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,255})\b`}},
+	// GitLab personal access tokens
+	{CategoryCredentials, false, false, RedactionRule{Name: "gitlab_token", Pattern: `\bglpat-[A-Za-z0-9_-]{20,50}`}},
+	// Slack bot, user, app and refresh tokens
+	{CategoryCredentials, false, false, RedactionRule{Name: "slack_token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,250}`}},
+	// Stripe live secret and restricted keys
+	{CategoryCredentials, false, false, RedactionRule{Name: "stripe_key", Pattern: `\b[rs]k_live_[A-Za-z0-9]{16,247}\b`}},
+	// Azure storage and service bus keys in connection strings
+	{CategoryCredentials, false, false, RedactionRule{
+		Name:    "azure_connection_string",
+		Pattern: `(?i)\b(?P<name>AccountKey|SharedAccessKey)=[A-Za-z0-9+/]{20,200}={0,2}`,
+		Mask:    TemplateMask("${name}=[REDACTED]"),
+	}},
+}
+
+// validIPv6 accepts IPv6 addresses with at least two non-empty groups, or
+// one after a leading "::" as in "::1", which rules out times, MAC
+// addresses and C++ scopes such as "std::".
+func validIPv6(s string) bool {
+	if net.ParseIP(s) == nil {
+		return false
+	}
+	groups := 0
+	for _, g := range strings.Split(s, ":") {
+		if g != "" {
+			groups++
+		}
+	}
+	return groups >= 2 || (groups == 1 && strings.HasPrefix(s, "::"))
+}
+
+// Detectors returns the built-in redaction rules in the given categories,
+// in the order they are applied.
+func Detectors(categories DetectorCategory) []RedactionRule {
+	var rules []RedactionRule
+	for _, d := range builtinDetectors {
+		if d.category&categories != 0 {
+			rules = append(rules, d.rule)
+		}
+	}
+	return rules
+}
+
+// NewSensitiveDataFilterWithDetectors creates a filter with the built-in
+// detectors of the given categories, e.g. CategoryPII|CategoryCredentials.
+func NewSensitiveDataFilterWithDetectors(categories DetectorCategory) (*SensitiveDataFilter, error) {
+	filter := NewEmptySensitiveDataFilter()
+	filter.timeout = DefaultFilterTimeout
+	if err := filter.EnableDetectors(categories); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// EnableDetectors adds the built-in detectors of the given categories,
+// skipping those whose rule name the filter already has.
+func (f *SensitiveDataFilter) EnableDetectors(categories DetectorCategory) error {
+	f.mu.RLock()
+	existing := make(map[string]bool, len(f.rules))
+	for _, rule := range f.rules {
+		existing[rule.name] = true
+	}
+	f.mu.RUnlock()
+
+	var rules []RedactionRule
+	for _, rule := range Detectors(categories) {
+		if !existing[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return f.AddRules(rules...)
+}
@@ -0,0 +1,190 @@
+package dd
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Encrypted values are written as
+//
+//	enc:v1:<kid>:<base64url(nonce | AES-GCM ciphertext)>
+//
+// with the key ID authenticated as additional data, so a token cannot be
+// moved to a different key ID.
+const (
+	encTokenPrefix = "enc:v1:"
+	encAADPrefix   = "dd-enc-v1:"
+)
+
+var (
+	encKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+	encTokenPattern = regexp.MustCompile(`enc:v1:([A-Za-z0-9_.-]{1,64}):([A-Za-z0-9_-]{16,})`)
+)
+
+// encryptionKey is the key used by MaskEncrypt.
+type encryptionKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+func newEncryptionKey(keyID string, key []byte) (*encryptionKey, error) {
+	if !encKeyIDPattern.MatchString(keyID) {
+		return nil, fmt.Errorf("%w: encryption key ID %q must be 1-64 letters, digits, '.', '_' or '-'", ErrInvalidPattern, keyID)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: encryption key must be 16, 24 or 32 bytes", ErrInvalidPattern)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptionKey{id: keyID, aead: aead}, nil
+}
+
+func (k *encryptionKey) aad() []byte {
+	return []byte(encAADPrefix + k.id)
+}
+
+// token encrypts value. Without a key, or if no nonce can be generated,
+// the value is fully redacted rather than written in clear.
+func (k *encryptionKey) token(value string) string {
+	if k == nil {
+		return redactedText
+	}
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(value)+k.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return redactedText
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(value), k.aad())
+	return encTokenPrefix + k.id + ":" + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// open decrypts the payload of a token issued under k.
+func (k *encryptionKey) open(payload string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return "", ErrDecryption
+	}
+	nonce, ciphertext := sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():]
+	plain, err := k.aead.Open(nil, nonce, ciphertext, k.aad())
+	if err != nil {
+		return "", ErrDecryption
+	}
+	return string(plain), nil
+}
+
+// protectedSpans returns the tokens in input that k issued, so that the
+// second filtering pass over a formatted record leaves them intact. Tokens
+// that do not decrypt are not protected, which keeps look-alike text from
+// shielding secrets.
+func (k *encryptionKey) protectedSpans(input string) []ruleMatch {
+	if k == nil || !strings.Contains(input, encTokenPrefix) {
+		return nil
+	}
+	var spans []ruleMatch
+	for _, loc := range encTokenPattern.FindAllStringSubmatchIndex(input, -1) {
+		if input[loc[2]:loc[3]] != k.id {
+			continue
+		}
+		if _, err := k.open(input[loc[4]:loc[5]]); err == nil {
+			spans = append(spans, ruleMatch{rule: -1, loc: loc})
+		}
+	}
+	return spans
+}
+
+// SetEncryptionKey sets the AES key (16, 24 or 32 bytes) used by
+// EncryptMask rules and key masks. keyID is written into every token; call
+// SetEncryptionKey again with a new ID to rotate the key, and keep the old
+// key to decrypt older logs.
+func (f *SensitiveDataFilter) SetEncryptionKey(keyID string, key []byte) error {
+	k, err := newEncryptionKey(keyID, key)
+	if err != nil {
+		return err
+	}
+	f.encKey.Store(k)
+	return nil
+}
+
+// DecryptLogLine replaces the encrypted values in line with their plain
+// text, using keys indexed by key ID. Tokens under unknown key IDs are left
+// as they are. In JSON records the plain text is escaped so the line stays
+// valid JSON. If a token fails to decrypt the rest of the line is still
+// processed and an error wrapping ErrDecryption is returned.
+func DecryptLogLine(line string, keys map[string][]byte) (string, error) {
+	if !strings.Contains(line, encTokenPrefix) {
+		return line, nil
+	}
+
+	jsonLine := strings.HasPrefix(strings.TrimSpace(line), "{")
+	ciphers := make(map[string]*encryptionKey)
+	var errs []error
+
+	result := encTokenPattern.ReplaceAllStringFunc(line, func(token string) string {
+		m := encTokenPattern.FindStringSubmatch(token)
+		keyID, payload := m[1], m[2]
+
+		k, ok := ciphers[keyID]
+		if !ok {
+			raw, found := keys[keyID]
+			if !found {
+				return token
+			}
+			var err error
+			if k, err = newEncryptionKey(keyID, raw); err != nil {
+				errs = append(errs, fmt.Errorf("key %q: %w", keyID, err))
+				return token
+			}
+			ciphers[keyID] = k
+		}
+
+		plain, err := k.open(payload)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("token under key %q: %w", keyID, err))
+			return token
+		}
+		if jsonLine {
+			quoted, _ := json.Marshal(plain)
+			return string(quoted[1 : len(quoted)-1])
+		}
+		return plain
+	})
+	return result, errors.Join(errs...)
+}
+
+// DecryptLog copies src to dst line by line, decrypting values with
+// DecryptLogLine. Lines with tokens that fail to decrypt are copied as far
+// as they could be decrypted and the first such error is returned at the
+// end.
+func DecryptLog(dst io.Writer, src io.Reader, keys map[string][]byte) error {
+	reader := bufio.NewReader(src)
+	var firstErr error
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			decrypted, err := DecryptLogLine(line, keys)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if _, err := io.WriteString(dst, decrypted); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return firstErr
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package dd
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+)
+
+// EntropyRuleName is the rule name used by entropy detection in RuleStats
+// and SetMask.
+const EntropyRuleName = "entropy"
+
+// defaultEntropyAllow lists benign high-entropy shapes: UUIDs and git
+// commit hashes.
+var defaultEntropyAllow = []string{
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+	`^[0-9a-f]{40}$`,
+}
+
+// EntropyConfig configures entropy-based secret detection. Zero values use
+// the defaults.
+type EntropyConfig struct {
+	// MinLength is the shortest token that is checked (default 20)
+	MinLength int
+	// Base64Threshold is the Shannon entropy, in bits per character, above
+	// which a token over the base64 alphabet is redacted (default 4.0)
+	Base64Threshold float64
+	// HexThreshold is the threshold for tokens made only of hex digits
+	// (default 3.0)
+	HexThreshold float64
+	// Allow lists regular expressions for benign tokens that are never
+	// redacted, in addition to UUIDs and git commit hashes
+	Allow []string
+	// Mask is applied to detected tokens; the zero value redacts them
+	Mask Mask
+}
+
+// DefaultEntropyConfig returns the default entropy detection settings.
+func DefaultEntropyConfig() EntropyConfig {
+	return EntropyConfig{
+		MinLength:       DefaultEntropyMinLength,
+		Base64Threshold: DefaultEntropyBase64Threshold,
+		HexThreshold:    DefaultEntropyHexThreshold,
+	}
+}
+
+// entropyDetector decides whether a candidate token looks random.
+type entropyDetector struct {
+	base64Threshold float64
+	hexThreshold    float64
+	allow           []*regexp.Regexp
+}
+
+// isSecret reports whether token has high entropy for its alphabet and
+// does not match an allowed shape.
+func (d *entropyDetector) isSecret(token string) bool {
+	var letter, digit bool
+	transitions := 0
+	for i := 0; i < len(token); i++ {
+		class := charClass(token[i])
+		switch class {
+		case 'a', 'A':
+			letter = true
+		case '0':
+			digit = true
+		}
+		if i > 0 && class != charClass(token[i-1]) {
+			transitions++
+		}
+	}
+	// Random tokens mix letters and digits; numbers and words do not.
+	if !letter || !digit {
+		return false
+	}
+
+	threshold := d.hexThreshold
+	if !isHexToken(token) {
+		threshold = d.base64Threshold
+		// Identifiers such as "user_id_1234567890" change character class
+		// rarely, while random base64 does so on most characters.
+		if float64(transitions) < minClassTransitions*float64(len(token)-1) {
+			return false
+		}
+	}
+
+	if shannonEntropy(token) < threshold {
+		return false
+	}
+	for _, re := range d.allow {
+		if re.MatchString(token) {
+			return false
+		}
+	}
+	return true
+}
+
+// minClassTransitions is the share of adjacent characters of a base64-like
+// token that must differ in class (lower, upper, digit, other).
+const minClassTransitions = 0.3
+
+// charClass returns 'a', 'A', '0' or '.' for lower-case letters, upper-case
+// letters, digits and anything else.
+func charClass(c byte) byte {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return 'a'
+	case c >= 'A' && c <= 'Z':
+		return 'A'
+	case c >= '0' && c <= '9':
+		return '0'
+	default:
+		return '.'
+	}
+}
+
+func isHexToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	n := float64(len(s))
+	entropy := 0.0
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// entropyRule builds the rule that matches candidate tokens and keeps
+// those the detector flags. Running as a rule keeps the filter's input
+// length limit, timeout and per-rule statistics in effect.
+func entropyRule(config EntropyConfig) (RedactionRule, error) {
+	if config.MinLength == 0 {
+		config.MinLength = DefaultEntropyMinLength
+	}
+	if config.Base64Threshold == 0 {
+		config.Base64Threshold = DefaultEntropyBase64Threshold
+	}
+	if config.HexThreshold == 0 {
+		config.HexThreshold = DefaultEntropyHexThreshold
+	}
+	if config.MinLength < 8 || config.MinLength > 1000 {
+		return RedactionRule{}, fmt.Errorf("%w: entropy MinLength must be between 8 and 1000", ErrInvalidPattern)
+	}
+	if config.Base64Threshold < 0 || config.Base64Threshold > 6 || config.HexThreshold < 0 || config.HexThreshold > 4 {
+		return RedactionRule{}, fmt.Errorf("%w: entropy thresholds must be within 0-6 (base64) and 0-4 (hex) bits", ErrInvalidPattern)
+	}
+
+	detector := &entropyDetector{
+		base64Threshold: config.Base64Threshold,
+		hexThreshold:    config.HexThreshold,
+	}
+	for _, pattern := range slices.Concat(defaultEntropyAllow, config.Allow) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return RedactionRule{}, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+		}
+		detector.allow = append(detector.allow, re)
+	}
+
+	return RedactionRule{
+		Name:     EntropyRuleName,
+		Pattern:  fmt.Sprintf(`[A-Za-z0-9+/_\-]{%d,}={0,2}`, config.MinLength),
+		Mask:     config.Mask,
+		Validate: detector.isSecret,
+	}, nil
+}
+
+// isTokenByte reports whether c belongs to the base64 and hex alphabets
+// scanned for secrets.
+func isTokenByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '+' || c == '/' || c == '_' || c == '-'
+}
+
+// findTokens returns the runs of token bytes of at least minLength, each
+// followed by up to two '=' padding characters. It finds the same matches
+// as the entropy rule's pattern in a single linear scan, which is much
+// faster than the regexp on large inputs.
+func findTokens(input string, minLength int) [][]int {
+	var matches [][]int
+	for i := 0; i < len(input); {
+		if !isTokenByte(input[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(input) && isTokenByte(input[i]) {
+			i++
+		}
+		if i-start < minLength {
+			continue
+		}
+		for pad := 0; pad < 2 && i < len(input) && input[i] == '='; pad++ {
+			i++
+		}
+		matches = append(matches, []int{start, i})
+	}
+	return matches
+}
+
+// EnableEntropyDetection redacts tokens of at least config.MinLength
+// characters whose Shannon entropy exceeds the threshold for their
+// alphabet, catching secrets that no pattern describes. It replaces any
+// previous entropy settings; detection runs after the other rules.
+func (f *SensitiveDataFilter) EnableEntropyDetection(config EntropyConfig) error {
+	rule, err := entropyRule(config)
+	if err != nil {
+		return err
+	}
+	compiled, err := compileRule(rule)
+	if err != nil {
+		return err
+	}
+	minLength := config.MinLength
+	if minLength == 0 {
+		minLength = DefaultEntropyMinLength
+	}
+	compiled.find = func(input string) [][]int { return findTokens(input, minLength) }
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rulesWithout(EntropyRuleName), compiled)
+	return nil
+}
+
+// DisableEntropyDetection turns entropy detection off.
+func (f *SensitiveDataFilter) DisableEntropyDetection() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = f.rulesWithout(EntropyRuleName)
+}
+
+// rulesWithout returns a copy of the rules without those named name.
+// The caller must hold f.mu.
+func (f *SensitiveDataFilter) rulesWithout(name string) []redactionRule {
+	rules := make([]redactionRule, 0, len(f.rules)+1)
+	for _, rule := range f.rules {
+		if rule.name != name {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
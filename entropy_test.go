@@ -0,0 +1,100 @@
+package dd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// ENTROPY DETECTION TESTS
+// ============================================================================
+
+func TestEntropyDetection(t *testing.T) {
+	filter := NewEmptySensitiveDataFilter()
+	if err := filter.EnableEntropyDetection(EntropyConfig{Allow: []string{`^build-`}}); err != nil {
+		t.Fatalf("EnableEntropyDetection failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"base64 token", "key=a8Kz3Qx9LmN4pR7tVb2YcW9s", "key=[REDACTED]"},
+		{"hex token", "sig 9f86d081884c7d659a2feaa0c55ad015 ok", "sig [REDACTED] ok"},
+		{"short token", "id a8Kz3Qx9Lm", "id a8Kz3Qx9Lm"},
+		{"identifier", "user_id_1234567890_abcdef", "user_id_1234567890_abcdef"},
+		{"words", "ThisIsAVeryLongIdentifierName", "ThisIsAVeryLongIdentifierName"},
+		{"uuid", "req 550e8400-e29b-41d4-a716-446655440000", "req 550e8400-e29b-41d4-a716-446655440000"},
+		{"git sha", "commit 3f786850e387550fdab836ed7e6dc881de23001b", "commit 3f786850e387550fdab836ed7e6dc881de23001b"},
+		{"custom allow", "build-a8Kz3Qx9LmN4pR7tVb2Y", "build-a8Kz3Qx9LmN4pR7tVb2Y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Filter(tt.input); got != tt.want {
+				t.Errorf("Filter(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	stats := filter.RuleStats()
+	if len(stats) != 1 || stats[0].Name != EntropyRuleName || stats[0].Hits != 2 {
+		t.Errorf("Unexpected entropy stats: %+v", stats)
+	}
+
+	filter.DisableEntropyDetection()
+	if got := filter.Filter("key=a8Kz3Qx9LmN4pR7tVb2YcW9s"); got != "key=a8Kz3Qx9LmN4pR7tVb2YcW9s" {
+		t.Errorf("Disabled detection should not redact, got %q", got)
+	}
+}
+
+func TestEntropyThresholds(t *testing.T) {
+	const token = "a8Kz3Qx9LmN4pR7tVb2YcW9s"
+
+	filter := NewEmptySensitiveDataFilter()
+	if err := filter.EnableEntropyDetection(EntropyConfig{Base64Threshold: 5.5}); err != nil {
+		t.Fatalf("EnableEntropyDetection failed: %v", err)
+	}
+	if got := filter.Filter(token); got != token {
+		t.Errorf("A higher threshold should keep the token, got %q", got)
+	}
+
+	// Enabling again replaces the previous settings.
+	if err := filter.EnableEntropyDetection(EntropyConfig{MinLength: 30}); err != nil {
+		t.Fatalf("EnableEntropyDetection failed: %v", err)
+	}
+	if got := filter.Filter(token); got != token {
+		t.Errorf("Tokens below MinLength should be kept, got %q", got)
+	}
+	if filter.PatternCount() != 1 {
+		t.Errorf("Expected a single entropy rule, got %d", filter.PatternCount())
+	}
+
+	for _, config := range []EntropyConfig{
+		{MinLength: 4},
+		{Base64Threshold: 7},
+		{HexThreshold: -1},
+		{Allow: []string{"("}},
+	} {
+		if err := filter.EnableEntropyDetection(config); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("EnableEntropyDetection(%+v) = %v, want ErrInvalidPattern", config, err)
+		}
+	}
+}
+
+func TestEntropyRespectsInputLimit(t *testing.T) {
+	filter := NewEmptySensitiveDataFilter()
+	if err := filter.EnableEntropyDetection(DefaultEntropyConfig()); err != nil {
+		t.Fatalf("EnableEntropyDetection failed: %v", err)
+	}
+
+	input := "token a8Kz3Qx9LmN4pR7tVb2YcW9s " + strings.Repeat("x", MaxInputLength)
+	got := filter.Filter(input)
+	if strings.Contains(got, "a8Kz3Qx9LmN4pR7tVb2YcW9s") {
+		t.Error("Token should be redacted in long input")
+	}
+	if !strings.HasSuffix(got, "[TRUNCATED FOR SECURITY]") {
+		t.Error("Input above maxInputLength should be truncated")
+	}
+}
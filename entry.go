@@ -0,0 +1,57 @@
+package dd
+
+import (
+	"io"
+	"time"
+
+	"github.com/cybergodev/dd/internal/caller"
+)
+
+// modulePath prefixes every function in this module; caller lookup for
+// entries skips frames under it.
+const modulePath = "github.com/cybergodev/dd"
+
+// Entry is a log record before formatting. Message and Fields have already
+// passed through the sensitive data filter.
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+	Fields  []Field
+
+	// File, Line and Function identify the call site outside this package.
+	File     string
+	Line     int
+	Function string
+}
+
+// EntryWriter is implemented by writers that consume structured records
+// rather than formatted lines, such as JournaldWriter. The Logger calls
+// WriteEntry instead of Write for writers attached to it directly; Write is
+// still used when the writer is wrapped by MultiWriter or another writer.
+type EntryWriter interface {
+	io.Writer
+	WriteEntry(entry *Entry) error
+}
+
+// record carries the unformatted parts of a log call to writeMessage so that
+// an Entry can be built for EntryWriters. msg and fields are already filtered.
+type record struct {
+	level  LogLevel
+	msg    string
+	fields []Field
+}
+
+// newEntry builds the Entry for rec, looking up the call site.
+func (l *Logger) newEntry(rec record) *Entry {
+	file, line, function := caller.Outside(1, modulePath)
+	return &Entry{
+		Time:     time.Now(),
+		Level:    rec.level,
+		Message:  rec.msg,
+		Fields:   rec.fields,
+		File:     file,
+		Line:     line,
+		Function: function,
+	}
+}
@@ -1,6 +1,10 @@
 package dd
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/cybergodev/dd/internal/types"
+)
 
 // Core errors
 var (
@@ -14,7 +18,7 @@ var (
 	ErrLoggerClosed = errors.New("logger is closed")
 
 	// ErrInvalidLevel is returned when an invalid log level is provided
-	ErrInvalidLevel = errors.New("invalid log level")
+	ErrInvalidLevel = types.ErrInvalidLevel
 
 	// ErrInvalidFormat is returned when an invalid log format is provided
 	ErrInvalidFormat = errors.New("invalid log format")
@@ -54,4 +58,43 @@ var (
 
 	// ErrInvalidPattern is returned when a regex pattern is invalid
 	ErrInvalidPattern = errors.New("invalid regex pattern")
+
+	// ErrNilLogger is returned when a nil logger is provided
+	ErrNilLogger = errors.New("logger cannot be nil")
+
+	// ErrInvalidConfigFile is returned when a configuration file cannot be read or parsed
+	ErrInvalidConfigFile = errors.New("invalid configuration file")
+
+	// ErrUnknownSinkType is returned when a configuration file names an unsupported sink type
+	ErrUnknownSinkType = errors.New("unknown sink type")
+
+	// ErrNoHealthyWriters is returned when every writer in a failover or load-balanced group failed
+	ErrNoHealthyWriters = errors.New("no healthy writers available")
+
+	// ErrInvalidNetwork is returned when a network writer is misconfigured
+	ErrInvalidNetwork = errors.New("invalid network configuration")
+
+	// ErrBufferFull is returned when a record cannot be buffered because the buffer is at capacity
+	ErrBufferFull = errors.New("buffer full")
+
+	// ErrWriterClosed is returned when writing to a closed writer
+	ErrWriterClosed = errors.New("writer is closed")
+
+	// ErrInvalidHTTPConfig is returned when an HTTP batch writer is misconfigured
+	ErrInvalidHTTPConfig = errors.New("invalid HTTP writer configuration")
+
+	// ErrInvalidSpoolConfig is returned when a spool writer is misconfigured
+	ErrInvalidSpoolConfig = errors.New("invalid spool writer configuration")
+
+	// ErrExpvarInUse is returned when an expvar name is already registered by other code
+	ErrExpvarInUse = errors.New("expvar name already in use")
+
+	// ErrJournaldUnsupported is returned when journald is not available on this platform
+	ErrJournaldUnsupported = errors.New("journald is only supported on Linux")
+
+	// ErrAuditVerification is returned when an audit log fails verification
+	ErrAuditVerification = errors.New("audit log verification failed")
+
+	// ErrDecryption is returned when an encrypted log value cannot be decrypted
+	ErrDecryption = errors.New("log value decryption failed")
 )
@@ -136,7 +136,15 @@ func contextShutdown() {
 
 	wg.Wait()
 	logger.Info("All workers finished")
-	logger.Close()
+
+	// Drain and close writers, but never block longer than the deadline
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	report, err := logger.Shutdown(shutdownCtx)
+	if err != nil {
+		fmt.Printf("Shutdown incomplete: %v (pending: %v)\n", err, report.Pending)
+	}
 }
 
 // 4. Application Lifecycle - Complete startup/shutdown pattern
@@ -213,14 +221,23 @@ func (app *Application) Stop() error {
 		close(done)
 	}()
 
+	// Kubernetes allows ~30s after SIGTERM; share one deadline between
+	// worker shutdown and log draining
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+
 	select {
 	case <-done:
 		app.logger.Info("Workers stopped")
-	case <-time.After(2 * time.Second):
+	case <-ctx.Done():
 		app.logger.Warn("Timeout waiting for workers")
 	}
 
-	return app.logger.Close()
+	report, err := app.logger.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("log shutdown: %w (closed %d, pending %v)", err, report.Closed, report.Pending)
+	}
+	return nil
 }
 
 func (app *Application) worker(id int) {
@@ -0,0 +1,214 @@
+package dd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// writerGroup holds the writers and health state shared by FailoverWriter
+// and LoadBalancedWriter. A writer that fails is marked unhealthy and
+// skipped for cooldown, then given a single trial write.
+type writerGroup struct {
+	entries  []writerEntry
+	cooldown time.Duration
+}
+
+func newWriterGroup(cooldown time.Duration, writers []io.Writer) (writerGroup, error) {
+	if cooldown <= 0 {
+		cooldown = DefaultWriterRetryInterval
+	}
+
+	if len(writers) == 0 {
+		return writerGroup{}, ErrNilWriter
+	}
+	entries := make([]writerEntry, len(writers))
+	for i, w := range writers {
+		if w == nil {
+			return writerGroup{}, fmt.Errorf("%w: writer[%d]", ErrNilWriter, i)
+		}
+		entries[i] = newWriterEntry(i, w)
+	}
+
+	return writerGroup{entries: entries, cooldown: cooldown}, nil
+}
+
+// tryWrite writes p to entries[i] if it is healthy, updating its health.
+// It returns attempted=false when the writer was skipped.
+func (g *writerGroup) tryWrite(i int, p []byte) (attempted bool, err error) {
+	entry := g.entries[i]
+	if !entry.health.allow(g.cooldown) {
+		return false, nil
+	}
+
+	start := time.Now()
+	n, err := entry.writer.Write(p)
+	entry.health.latency.observe(time.Since(start))
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		entry.health.recordFailure(err, 1, g.cooldown)
+		return true, fmt.Errorf("writer[%d]: %w", i, err)
+	}
+
+	entry.health.recordSuccess(n)
+	return true, nil
+}
+
+// Health returns a snapshot of each writer's state, in configuration order.
+func (g *writerGroup) Health() []WriterStats {
+	stats := make([]WriterStats, len(g.entries))
+	for i, entry := range g.entries {
+		stats[i] = entry.health.snapshot(describeWriter(entry.id, entry.writer))
+	}
+	return stats
+}
+
+// Healthy reports whether at least one writer is currently accepting writes.
+func (g *writerGroup) Healthy() bool {
+	now := time.Now().UnixNano()
+	for _, entry := range g.entries {
+		if entry.health.disabledUntil.Load() <= now {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *writerGroup) writers() []io.Writer {
+	writers := make([]io.Writer, len(g.entries))
+	for i, entry := range g.entries {
+		writers[i] = entry.writer
+	}
+	return writers
+}
+
+// Sync syncs every wrapped writer, returning all errors joined.
+func (g *writerGroup) Sync() error {
+	var errs []error
+	for i, entry := range g.entries {
+		if err := syncWriter(entry.writer); err != nil {
+			errs = append(errs, fmt.Errorf("writer[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown drains and closes every wrapped writer in parallel.
+func (g *writerGroup) Shutdown(ctx context.Context) error {
+	report := &ShutdownReport{}
+	shutdownWriters(ctx, indexedEntries(g.writers()), report)
+
+	errs := make([]error, 0, len(report.Failed)+1)
+	for _, failed := range report.Failed {
+		errs = append(errs, failed)
+	}
+	if len(report.Pending) > 0 {
+		errs = append(errs, fmt.Errorf("%d writers still draining: %w", len(report.Pending), ctx.Err()))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every wrapped writer that implements io.Closer.
+func (g *writerGroup) Close() error {
+	return g.Shutdown(context.Background())
+}
+
+// FailoverWriter sends each record to the first healthy writer in priority
+// order. A writer that fails is skipped for the cooldown period, after which
+// it is tried again, so traffic returns to the primary once it recovers.
+type FailoverWriter struct {
+	writerGroup
+}
+
+// NewFailoverWriter creates a FailoverWriter; writers are listed from highest
+// to lowest priority and must not be nil. A cooldown of zero uses
+// DefaultWriterRetryInterval.
+func NewFailoverWriter(cooldown time.Duration, writers ...io.Writer) (*FailoverWriter, error) {
+	group, err := newWriterGroup(cooldown, writers)
+	if err != nil {
+		return nil, err
+	}
+	return &FailoverWriter{writerGroup: group}, nil
+}
+
+func (fw *FailoverWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var errs []error
+	for i := range fw.entries {
+		attempted, err := fw.tryWrite(i, p)
+		if attempted && err == nil {
+			return len(p), nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return 0, ErrNoHealthyWriters
+	}
+	return 0, fmt.Errorf("%w: %w", ErrNoHealthyWriters, errors.Join(errs...))
+}
+
+// Active returns the index of the writer that would receive the next record,
+// or -1 if every writer is cooling down.
+func (fw *FailoverWriter) Active() int {
+	now := time.Now().UnixNano()
+	for i, entry := range fw.entries {
+		if entry.health.disabledUntil.Load() <= now {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadBalancedWriter spreads records round-robin across healthy writers.
+// If the chosen writer fails the record is retried on the next one, so a
+// record is only lost when every writer fails.
+type LoadBalancedWriter struct {
+	writerGroup
+	next atomic.Uint64
+}
+
+// NewLoadBalancedWriter creates a LoadBalancedWriter; writers must not be
+// nil. A cooldown of zero uses DefaultWriterRetryInterval.
+func NewLoadBalancedWriter(cooldown time.Duration, writers ...io.Writer) (*LoadBalancedWriter, error) {
+	group, err := newWriterGroup(cooldown, writers)
+	if err != nil {
+		return nil, err
+	}
+	return &LoadBalancedWriter{writerGroup: group}, nil
+}
+
+func (lw *LoadBalancedWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	count := len(lw.entries)
+	start := int((lw.next.Add(1) - 1) % uint64(count))
+
+	var errs []error
+	for offset := range count {
+		attempted, err := lw.tryWrite((start+offset)%count, p)
+		if attempted && err == nil {
+			return len(p), nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return 0, ErrNoHealthyWriters
+	}
+	return 0, fmt.Errorf("%w: %w", ErrNoHealthyWriters, errors.Join(errs...))
+}
@@ -0,0 +1,183 @@
+package dd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// FAILOVER WRITER TESTS
+// ============================================================================
+
+func TestFailoverWriter(t *testing.T) {
+	primary := &toggleWriter{}
+	secondary := &toggleWriter{}
+
+	fw, err := NewFailoverWriter(50*time.Millisecond, primary, secondary)
+	if err != nil {
+		t.Fatalf("NewFailoverWriter failed: %v", err)
+	}
+
+	fw.Write([]byte("one\n"))
+	if !strings.Contains(primary.String(), "one") || secondary.Len() != 0 {
+		t.Errorf("Healthy primary should receive records, primary=%q secondary=%q", primary.String(), secondary.String())
+	}
+
+	primary.broken.Store(true)
+	if _, err := fw.Write([]byte("two\n")); err != nil {
+		t.Fatalf("Failover write should succeed on secondary: %v", err)
+	}
+	fw.Write([]byte("three\n"))
+
+	if got := secondary.String(); got != "two\nthree\n" {
+		t.Errorf("Secondary should receive records while primary is down, got %q", got)
+	}
+	if primary.attempts.Load() != 2 {
+		t.Errorf("Primary should be skipped during cooldown, got %d attempts", primary.attempts.Load())
+	}
+	if fw.Active() != 1 {
+		t.Errorf("Expected active writer 1, got %d", fw.Active())
+	}
+	health := fw.Health()
+	if !health[0].Disabled || health[0].Failures != 1 || health[1].Writes != 2 {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+
+	primary.broken.Store(false)
+	time.Sleep(60 * time.Millisecond)
+
+	fw.Write([]byte("four\n"))
+	if !strings.Contains(primary.String(), "four") {
+		t.Errorf("Writes should return to primary after cooldown, got %q", primary.String())
+	}
+	if fw.Active() != 0 {
+		t.Errorf("Expected active writer 0 after recovery, got %d", fw.Active())
+	}
+}
+
+func TestFailoverWriterAllFailing(t *testing.T) {
+	a := &toggleWriter{}
+	b := &toggleWriter{}
+	a.broken.Store(true)
+	b.broken.Store(true)
+
+	fw, err := NewFailoverWriter(time.Minute, a, b)
+	if err != nil {
+		t.Fatalf("NewFailoverWriter failed: %v", err)
+	}
+
+	if _, err := fw.Write([]byte("x")); !errors.Is(err, ErrNoHealthyWriters) {
+		t.Errorf("Expected ErrNoHealthyWriters, got %v", err)
+	}
+	if fw.Healthy() {
+		t.Error("Group should be unhealthy when every writer is cooling down")
+	}
+	if _, err := fw.Write([]byte("y")); !errors.Is(err, ErrNoHealthyWriters) {
+		t.Errorf("Expected ErrNoHealthyWriters while cooling down, got %v", err)
+	}
+	if a.attempts.Load() != 1 || b.attempts.Load() != 1 {
+		t.Errorf("Cooling writers should not be retried, got %d/%d attempts", a.attempts.Load(), b.attempts.Load())
+	}
+}
+
+func TestFailoverWriterNoWriters(t *testing.T) {
+	if _, err := NewFailoverWriter(time.Second); !errors.Is(err, ErrNilWriter) {
+		t.Errorf("Expected ErrNilWriter, got %v", err)
+	}
+	if _, err := NewLoadBalancedWriter(time.Second, nil); !errors.Is(err, ErrNilWriter) {
+		t.Errorf("Expected ErrNilWriter, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := NewFailoverWriter(time.Second, &buf, nil); !errors.Is(err, ErrNilWriter) || !strings.Contains(err.Error(), "writer[1]") {
+		t.Errorf("Expected ErrNilWriter for writer[1], got %v", err)
+	}
+	if _, err := NewLoadBalancedWriter(time.Second, nil, &buf); !errors.Is(err, ErrNilWriter) || !strings.Contains(err.Error(), "writer[0]") {
+		t.Errorf("Expected ErrNilWriter for writer[0], got %v", err)
+	}
+}
+
+// ============================================================================
+// LOAD BALANCED WRITER TESTS
+// ============================================================================
+
+func TestLoadBalancedWriter(t *testing.T) {
+	var a, b, c bytes.Buffer
+	lw, err := NewLoadBalancedWriter(time.Minute, &a, &b, &c)
+	if err != nil {
+		t.Fatalf("NewLoadBalancedWriter failed: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if _, err := lw.Write([]byte("r\n")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for i, buf := range []*bytes.Buffer{&a, &b, &c} {
+		if got := strings.Count(buf.String(), "r\n"); got != 2 {
+			t.Errorf("Writer %d should receive 2 records, got %d", i, got)
+		}
+	}
+}
+
+func TestLoadBalancedWriterSkipsUnhealthy(t *testing.T) {
+	good := &toggleWriter{}
+	bad := &toggleWriter{}
+	bad.broken.Store(true)
+
+	lw, err := NewLoadBalancedWriter(time.Minute, bad, good)
+	if err != nil {
+		t.Fatalf("NewLoadBalancedWriter failed: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := lw.Write([]byte("r\n")); err != nil {
+			t.Fatalf("Write should be retried on a healthy writer: %v", err)
+		}
+	}
+
+	if got := strings.Count(good.String(), "r\n"); got != 4 {
+		t.Errorf("Healthy writer should receive all 4 records, got %d", got)
+	}
+	if bad.attempts.Load() != 1 {
+		t.Errorf("Unhealthy writer should be skipped after first failure, got %d attempts", bad.attempts.Load())
+	}
+	if health := lw.Health(); !health[0].Disabled || health[1].Disabled {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+}
+
+func TestWriterCombinatorsCompose(t *testing.T) {
+	var sink bytes.Buffer
+	bw, err := NewBufferedWriter(&sink, 4096)
+	if err != nil {
+		t.Fatalf("NewBufferedWriter failed: %v", err)
+	}
+	fw, err := NewFailoverWriter(time.Second, bw)
+	if err != nil {
+		t.Fatalf("NewFailoverWriter failed: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{fw}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Info("through failover")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !strings.Contains(sink.String(), "through failover") {
+		t.Errorf("Sync should flush through the failover writer, got %q", sink.String())
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
@@ -20,6 +20,7 @@ type MessageFormatter struct {
 	includeLevel  bool
 	fullPath      bool
 	dynamicCaller bool
+	color         bool
 	jsonConfig    *JSONOptions
 }
 
@@ -33,6 +34,7 @@ func newMessageFormatter(config *LoggerConfig) *MessageFormatter {
 		includeLevel:  config.IncludeLevel,
 		fullPath:      config.FullPath,
 		dynamicCaller: config.DynamicCaller,
+		color:         config.Color,
 		jsonConfig:    config.JSON,
 	}
 }
@@ -43,12 +45,6 @@ func (f *MessageFormatter) formatMessage(level LogLevel, callerDepth int, args .
 	return f.formatWithMessage(level, callerDepth, message, nil)
 }
 
-// formatMessagef formats a formatted log message (unified entry point)
-func (f *MessageFormatter) formatMessagef(level LogLevel, callerDepth int, format string, args ...any) string {
-	message := fmt.Sprintf(format, args...)
-	return f.formatWithMessage(level, callerDepth, message, nil)
-}
-
 // formatMessageWith formats a structured log message with fields (unified entry point)
 func (f *MessageFormatter) formatMessageWith(level LogLevel, callerDepth int, msg string, fields []Field) string {
 	return f.formatWithMessage(level, callerDepth, msg, fields)
@@ -92,6 +88,17 @@ func (f *MessageFormatter) formatText(level LogLevel, callerDepth int, message s
 	return baseMsg
 }
 
+// colorizeLevel wraps the first level label of a text record in the level's
+// color. It runs after security filtering, which strips escape sequences.
+func (f *MessageFormatter) colorizeLevel(msg string, level LogLevel) string {
+	color := level.Color()
+	if !f.color || !f.includeLevel || f.format != FormatText || color == "" {
+		return msg
+	}
+	label := "[" + level.String() + "]"
+	return strings.Replace(msg, label, color+label+types.ColorReset, 1)
+}
+
 // formatJSON handles JSON formatting with unified logic
 func (f *MessageFormatter) formatJSON(level LogLevel, callerDepth int, message string, fields []Field) string {
 	fieldNames := f.getJSONFieldNames()
@@ -0,0 +1,589 @@
+package dd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchRecord is a single log record queued for an HTTP batch.
+// Data never includes the trailing newline.
+type BatchRecord struct {
+	Time time.Time
+	Data []byte
+}
+
+// BodyBuilder turns a batch of records into an HTTP request body.
+type BodyBuilder interface {
+	ContentType() string
+	Build(records []BatchRecord) ([]byte, error)
+}
+
+// HTTPBatchWriterConfig configures an HTTPBatchWriter.
+type HTTPBatchWriterConfig struct {
+	URL     string
+	Method  string // defaults to POST
+	Headers map[string]string
+
+	// BearerToken sets "Authorization: Bearer <token>".
+	BearerToken string
+	// Username and Password enable HTTP basic authentication.
+	Username string
+	Password string
+
+	// Body builds request bodies (defaults to NDJSONBody).
+	Body   BodyBuilder
+	Client *http.Client
+	// Compress gzips request bodies and sets Content-Encoding.
+	Compress bool
+
+	// A batch is sent when it reaches MaxBatchRecords or MaxBatchBytes,
+	// or when FlushInterval has passed since the last send.
+	MaxBatchRecords int
+	MaxBatchBytes   int
+	FlushInterval   time.Duration
+
+	// QueueSize is the number of full batches that may wait for the sender
+	// before new records are rejected with ErrBufferFull.
+	QueueSize int
+
+	// MaxRetries bounds retries of 5xx, 429 and transport errors
+	// (0 uses the default, negative disables retries).
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the delay between retries; a
+	// server's Retry-After hint is honoured up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnError is called when a batch is dropped after exhausting retries
+	// or on a non-retryable response.
+	OnError func(error)
+}
+
+// HTTPBatchWriter collects records and POSTs them in batches to a log
+// ingestion endpoint. Sending happens on a background goroutine, so Write
+// never blocks on the network.
+type HTTPBatchWriter struct {
+	url        string
+	method     string
+	headers    http.Header
+	body       BodyBuilder
+	client     *http.Client
+	compress   bool
+	maxRecords int
+	maxBytes   int
+	interval   time.Duration
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	onError    func(error)
+
+	mu         sync.Mutex
+	batch      []BatchRecord
+	batchBytes int
+
+	queue    chan []BatchRecord
+	flushReq chan chan error
+	stop     chan struct{}
+	closed   atomic.Bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	sent    atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// HTTPBatchStats reports record counts for an HTTPBatchWriter.
+type HTTPBatchStats struct {
+	Sent    uint64 // records accepted by the endpoint
+	Failed  uint64 // records dropped after a failed send
+	Dropped uint64 // records rejected because the queue was full
+}
+
+// NewHTTPBatchWriter validates config and starts the background sender.
+func NewHTTPBatchWriter(config HTTPBatchWriterConfig) (*HTTPBatchWriter, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("%w: empty URL", ErrInvalidHTTPConfig)
+	}
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	if _, err := http.NewRequest(config.Method, config.URL, nil); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHTTPConfig, err)
+	}
+	if config.Body == nil {
+		config.Body = NDJSONBody()
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+	if config.MaxBatchRecords <= 0 {
+		config.MaxBatchRecords = DefaultHTTPBatchRecords
+	}
+	if config.MaxBatchBytes <= 0 {
+		config.MaxBatchBytes = DefaultHTTPBatchBytes
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultHTTPFlushInterval
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultHTTPQueueSize
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultHTTPMaxRetries
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultMinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(DefaultMaxBackoff, config.MinBackoff)
+	}
+
+	headers := make(http.Header, len(config.Headers)+2)
+	for k, v := range config.Headers {
+		headers.Set(k, v)
+	}
+	if config.BearerToken != "" {
+		headers.Set("Authorization", "Bearer "+config.BearerToken)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hw := &HTTPBatchWriter{
+		url:        config.URL,
+		method:     config.Method,
+		headers:    headers,
+		body:       config.Body,
+		client:     config.Client,
+		compress:   config.Compress,
+		maxRecords: config.MaxBatchRecords,
+		maxBytes:   config.MaxBatchBytes,
+		interval:   config.FlushInterval,
+		maxRetries: config.MaxRetries,
+		minBackoff: config.MinBackoff,
+		maxBackoff: config.MaxBackoff,
+		onError:    config.OnError,
+		queue:      make(chan []BatchRecord, config.QueueSize),
+		flushReq:   make(chan chan error),
+		stop:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	if config.Username != "" || config.Password != "" {
+		hw.headers.Set("Authorization", basicAuthHeader(config.Username, config.Password))
+	}
+
+	hw.wg.Add(1)
+	go hw.sendRoutine()
+
+	return hw, nil
+}
+
+func basicAuthHeader(username, password string) string {
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.SetBasicAuth(username, password)
+	return req.Header.Get("Authorization")
+}
+
+func (hw *HTTPBatchWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	data := bytes.TrimSuffix(p, []byte{'\n'})
+	record := BatchRecord{Time: time.Now(), Data: append([]byte(nil), data...)}
+
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	if hw.closed.Load() {
+		return 0, ErrWriterClosed
+	}
+
+	// A full batch that could not be queued earlier blocks new records.
+	if hw.batchFullLocked() && !hw.enqueueLocked() {
+		hw.dropped.Add(1)
+		return 0, ErrBufferFull
+	}
+
+	hw.batch = append(hw.batch, record)
+	hw.batchBytes += len(record.Data)
+
+	if hw.batchFullLocked() {
+		hw.enqueueLocked()
+	}
+	return len(p), nil
+}
+
+func (hw *HTTPBatchWriter) batchFullLocked() bool {
+	return len(hw.batch) >= hw.maxRecords || hw.batchBytes >= hw.maxBytes
+}
+
+// enqueueLocked hands the current batch to the sender without blocking.
+func (hw *HTTPBatchWriter) enqueueLocked() bool {
+	if len(hw.batch) == 0 {
+		return true
+	}
+	select {
+	case hw.queue <- hw.batch:
+		hw.batch = nil
+		hw.batchBytes = 0
+		return true
+	default:
+		return false
+	}
+}
+
+func (hw *HTTPBatchWriter) takeBatch() []BatchRecord {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	batch := hw.batch
+	hw.batch = nil
+	hw.batchBytes = 0
+	return batch
+}
+
+func (hw *HTTPBatchWriter) sendRoutine() {
+	defer hw.wg.Done()
+
+	ticker := time.NewTicker(hw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case batch := <-hw.queue:
+			hw.send(batch)
+		case <-ticker.C:
+			if batch := hw.takeBatch(); len(batch) > 0 {
+				hw.send(batch)
+			}
+		case done := <-hw.flushReq:
+			done <- hw.drain()
+		case <-hw.stop:
+			_ = hw.drain()
+			return
+		}
+	}
+}
+
+// drain sends every queued batch followed by the partial batch.
+func (hw *HTTPBatchWriter) drain() error {
+	var errs []error
+	for {
+		select {
+		case batch := <-hw.queue:
+			errs = append(errs, hw.send(batch))
+		default:
+			if batch := hw.takeBatch(); len(batch) > 0 {
+				errs = append(errs, hw.send(batch))
+			}
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// send delivers one batch, retrying retryable failures with backoff.
+func (hw *HTTPBatchWriter) send(batch []BatchRecord) error {
+	body, err := hw.body.Build(batch)
+	if err == nil && hw.compress {
+		body, err = gzipBytes(body)
+	}
+	if err != nil {
+		return hw.fail(batch, fmt.Errorf("build batch body: %w", err))
+	}
+
+	backoff := hw.minBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := hw.post(body)
+		if err == nil {
+			hw.sent.Add(uint64(len(batch)))
+			return nil
+		}
+
+		var permanent *httpStatusError
+		if (errors.As(err, &permanent) && !permanent.retryable()) || attempt >= hw.maxRetries {
+			return hw.fail(batch, err)
+		}
+
+		// Honour Retry-After, but never beyond MaxBackoff so that a large
+		// hint cannot stall the sender (and Close) for hours
+		wait := backoff
+		if retryAfter > 0 {
+			wait = min(retryAfter, hw.maxBackoff)
+		}
+		backoff = min(backoff*2, hw.maxBackoff)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-hw.ctx.Done():
+			timer.Stop()
+			return hw.fail(batch, fmt.Errorf("%w (last error: %w)", hw.ctx.Err(), err))
+		case <-timer.C:
+		}
+	}
+}
+
+func (hw *HTTPBatchWriter) fail(batch []BatchRecord, err error) error {
+	hw.failed.Add(uint64(len(batch)))
+	err = fmt.Errorf("dropped batch of %d records: %w", len(batch), err)
+	if hw.onError != nil {
+		hw.onError(err)
+	}
+	return err
+}
+
+// post sends a single request and returns the server's Retry-After hint.
+func (hw *HTTPBatchWriter) post(body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(hw.ctx, hw.method, hw.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range hw.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", hw.body.ContentType())
+	if hw.compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := hw.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	return parseRetryAfter(resp.Header.Get("Retry-After")), &httpStatusError{code: resp.StatusCode}
+}
+
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d", e.code)
+}
+
+func (e *httpStatusError) retryable() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// parseRetryAfter accepts either delay-seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return max(time.Until(when), 0)
+	}
+	return 0
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sync sends everything buffered so far and waits for the result.
+func (hw *HTTPBatchWriter) Sync() error {
+	if hw.closed.Load() {
+		return nil
+	}
+	done := make(chan error, 1)
+	select {
+	case hw.flushReq <- done:
+		return <-done
+	case <-hw.stop:
+		return nil
+	}
+}
+
+// Shutdown stops accepting records and sends whatever is buffered. If ctx
+// expires first, in-flight retries are abandoned and the remaining records
+// are counted as failed.
+func (hw *HTTPBatchWriter) Shutdown(ctx context.Context) error {
+	// Taking the batch lock orders this against in-progress writes, so
+	// nothing is appended after the final drain.
+	hw.mu.Lock()
+	if hw.closed.Load() {
+		hw.mu.Unlock()
+		return nil
+	}
+	hw.closed.Store(true)
+	hw.mu.Unlock()
+
+	close(hw.stop)
+	if err := waitGroupContext(ctx, &hw.wg); err != nil {
+		hw.cancel()
+		hw.wg.Wait()
+		return fmt.Errorf("http batch writer: %w", err)
+	}
+	hw.cancel()
+	return nil
+}
+
+// Close is Shutdown without a deadline.
+func (hw *HTTPBatchWriter) Close() error {
+	return hw.Shutdown(context.Background())
+}
+
+// Stats returns record counters.
+func (hw *HTTPBatchWriter) Stats() HTTPBatchStats {
+	return HTTPBatchStats{
+		Sent:    hw.sent.Load(),
+		Failed:  hw.failed.Load(),
+		Dropped: hw.dropped.Load(),
+	}
+}
+
+// ===== Body builders =====
+
+type bodyBuilderFunc struct {
+	contentType string
+	build       func(records []BatchRecord) ([]byte, error)
+}
+
+func (b bodyBuilderFunc) ContentType() string { return b.contentType }
+func (b bodyBuilderFunc) Build(records []BatchRecord) ([]byte, error) {
+	return b.build(records)
+}
+
+// NewBodyBuilder creates a BodyBuilder from a content type and a function.
+func NewBodyBuilder(contentType string, build func(records []BatchRecord) ([]byte, error)) BodyBuilder {
+	return bodyBuilderFunc{contentType: contentType, build: build}
+}
+
+// jsonDocument returns the record as raw JSON if it is a JSON object,
+// otherwise wraps the text in {"message": ...}.
+func jsonDocument(data []byte) json.RawMessage {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed) {
+		return trimmed
+	}
+	wrapped, _ := json.Marshal(map[string]string{DefaultMessageField: string(data)})
+	return wrapped
+}
+
+// NDJSONBody sends records as newline-delimited lines, unchanged.
+func NDJSONBody() BodyBuilder {
+	return NewBodyBuilder("application/x-ndjson", func(records []BatchRecord) ([]byte, error) {
+		var buf bytes.Buffer
+		for _, r := range records {
+			buf.Write(r.Data)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// JSONArrayBody sends records as a JSON array of objects; text records are
+// wrapped as {"message": ...}.
+func JSONArrayBody() BodyBuilder {
+	return NewBodyBuilder("application/json", func(records []BatchRecord) ([]byte, error) {
+		docs := make([]json.RawMessage, len(records))
+		for i, r := range records {
+			docs[i] = jsonDocument(r.Data)
+		}
+		return json.Marshal(docs)
+	})
+}
+
+// ElasticsearchBulkBody formats records for the Elasticsearch _bulk API,
+// indexing each document into index.
+func ElasticsearchBulkBody(index string) BodyBuilder {
+	action, _ := json.Marshal(map[string]map[string]string{"index": {"_index": index}})
+	return NewBodyBuilder("application/x-ndjson", func(records []BatchRecord) ([]byte, error) {
+		var buf bytes.Buffer
+		for _, r := range records {
+			buf.Write(action)
+			buf.WriteByte('\n')
+			buf.Write(jsonDocument(r.Data))
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// LokiBody formats records as a single Loki push stream with labels.
+func LokiBody(labels map[string]string) BodyBuilder {
+	return NewBodyBuilder("application/json", func(records []BatchRecord) ([]byte, error) {
+		values := make([][2]string, len(records))
+		for i, r := range records {
+			values[i] = [2]string{strconv.FormatInt(r.Time.UnixNano(), 10), string(r.Data)}
+		}
+		payload := map[string]any{
+			"streams": []map[string]any{{
+				"stream": labels,
+				"values": values,
+			}},
+		}
+		return json.Marshal(payload)
+	})
+}
+
+// SplunkHECConfig holds optional event metadata for SplunkHECBody.
+type SplunkHECConfig struct {
+	Host       string
+	Source     string
+	SourceType string
+	Index      string
+}
+
+// SplunkHECBody formats records as Splunk HTTP Event Collector events.
+// Authenticate with Headers: {"Authorization": "Splunk <token>"}.
+func SplunkHECBody(config SplunkHECConfig) BodyBuilder {
+	type hecEvent struct {
+		Time       float64         `json:"time"`
+		Host       string          `json:"host,omitempty"`
+		Source     string          `json:"source,omitempty"`
+		SourceType string          `json:"sourcetype,omitempty"`
+		Index      string          `json:"index,omitempty"`
+		Event      json.RawMessage `json:"event"`
+	}
+
+	return NewBodyBuilder("application/json", func(records []BatchRecord) ([]byte, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, r := range records {
+			event := hecEvent{
+				Time:       float64(r.Time.UnixNano()) / float64(time.Second),
+				Host:       config.Host,
+				Source:     config.Source,
+				SourceType: config.SourceType,
+				Index:      config.Index,
+				Event:      jsonDocument(r.Data),
+			}
+			if err := enc.Encode(event); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	})
+}
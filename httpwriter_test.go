@@ -0,0 +1,403 @@
+package dd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// HTTP BATCH WRITER TESTS
+// ============================================================================
+
+// capturedRequest holds a decoded request received by the test server
+type capturedRequest struct {
+	header http.Header
+	body   string
+}
+
+// batchServer records requests and answers with the next queued status code
+type batchServer struct {
+	mu       sync.Mutex
+	requests []capturedRequest
+	statuses []int
+	headers  []map[string]string
+	*httptest.Server
+}
+
+func newBatchServer(t *testing.T, statuses ...int) *batchServer {
+	t.Helper()
+	bs := &batchServer{statuses: statuses}
+	bs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("Invalid gzip body: %v", err)
+				return
+			}
+			reader = gr
+		}
+		body, _ := io.ReadAll(reader)
+
+		bs.mu.Lock()
+		bs.requests = append(bs.requests, capturedRequest{header: r.Header.Clone(), body: string(body)})
+		status := http.StatusOK
+		if len(bs.statuses) > 0 {
+			status = bs.statuses[0]
+			bs.statuses = bs.statuses[1:]
+		}
+		bs.mu.Unlock()
+
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(bs.Close)
+	return bs
+}
+
+func (bs *batchServer) captured() []capturedRequest {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return append([]capturedRequest(nil), bs.requests...)
+}
+
+func TestHTTPBatchWriterBatchesBySize(t *testing.T) {
+	srv := newBatchServer(t)
+
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{
+		URL:             srv.URL,
+		Headers:         map[string]string{"X-Tenant": "team-a"},
+		BearerToken:     "s3cr3t",
+		Compress:        true,
+		MaxBatchRecords: 3,
+		FlushInterval:   time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+	defer hw.Close()
+
+	for _, msg := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		hw.Write([]byte(msg))
+	}
+	if err := hw.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	reqs := srv.captured()
+	if len(reqs) != 2 {
+		t.Fatalf("Expected 2 batches, got %d", len(reqs))
+	}
+	if reqs[0].body != "a\nb\nc\n" || reqs[1].body != "d\n" {
+		t.Errorf("Unexpected batch bodies: %q, %q", reqs[0].body, reqs[1].body)
+	}
+	if got := reqs[0].header.Get("Authorization"); got != "Bearer s3cr3t" {
+		t.Errorf("Expected bearer auth header, got %q", got)
+	}
+	if got := reqs[0].header.Get("X-Tenant"); got != "team-a" {
+		t.Errorf("Expected custom header, got %q", got)
+	}
+	if got := reqs[0].header.Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Expected NDJSON content type, got %q", got)
+	}
+	if stats := hw.Stats(); stats.Sent != 4 {
+		t.Errorf("Expected 4 sent records, got %+v", stats)
+	}
+}
+
+func TestHTTPBatchWriterFlushInterval(t *testing.T) {
+	srv := newBatchServer(t)
+
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{URL: srv.URL, FlushInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+	defer hw.Close()
+
+	hw.Write([]byte("timed\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(srv.captured()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if reqs := srv.captured(); len(reqs) != 1 || reqs[0].body != "timed\n" {
+		t.Errorf("Expected one timed flush, got %+v", reqs)
+	}
+}
+
+func TestHTTPBatchWriterRetries(t *testing.T) {
+	srv := newBatchServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{
+		URL:        srv.URL,
+		Username:   "user",
+		Password:   "pass",
+		MinBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+	defer hw.Close()
+
+	hw.Write([]byte("retry me\n"))
+	if err := hw.Sync(); err != nil {
+		t.Fatalf("Sync should succeed after retries: %v", err)
+	}
+
+	reqs := srv.captured()
+	if len(reqs) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(reqs))
+	}
+	if user, pass, ok := (&http.Request{Header: reqs[0].header}).BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("Expected basic auth, got %q/%q", user, pass)
+	}
+}
+
+func TestHTTPBatchWriterRetryAfterClamped(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{
+		URL:        srv.URL,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+	defer hw.Close()
+
+	hw.Write([]byte("throttled\n"))
+	done := make(chan error, 1)
+	go func() { done <- hw.Sync() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Sync should succeed after retry: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Retry-After of one day was not clamped to MaxBackoff")
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestHTTPBatchWriterPermanentFailure(t *testing.T) {
+	srv := newBatchServer(t, http.StatusBadRequest)
+
+	var reported atomic.Int32
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{
+		URL:     srv.URL,
+		OnError: func(error) { reported.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+	defer hw.Close()
+
+	hw.Write([]byte("bad\n"))
+	if err := hw.Sync(); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Expected status 400 error, got %v", err)
+	}
+	if len(srv.captured()) != 1 {
+		t.Errorf("4xx responses should not be retried, got %d attempts", len(srv.captured()))
+	}
+	if reported.Load() != 1 || hw.Stats().Failed != 1 {
+		t.Errorf("Expected failure to be reported and counted, got reported=%d stats=%+v", reported.Load(), hw.Stats())
+	}
+}
+
+func TestHTTPBatchWriterShutdown(t *testing.T) {
+	srv := newBatchServer(t)
+
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{URL: srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{hw}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Info("final words")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := logger.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if reqs := srv.captured(); len(reqs) != 1 || !strings.Contains(reqs[0].body, "final words") {
+		t.Errorf("Expected buffered record to be sent on shutdown, got %+v", reqs)
+	}
+	if _, err := hw.Write([]byte("late\n")); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed, got %v", err)
+	}
+}
+
+func TestHTTPBatchWriterShutdownDeadline(t *testing.T) {
+	srv := newBatchServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	hw, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{
+		URL:        srv.URL,
+		MinBackoff: time.Hour,
+		MaxRetries: 5,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPBatchWriter failed: %v", err)
+	}
+	hw.Write([]byte("stuck\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := hw.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if hw.Stats().Failed != 1 {
+		t.Errorf("Abandoned record should be counted as failed, got %+v", hw.Stats())
+	}
+}
+
+func TestHTTPBatchWriterInvalidConfig(t *testing.T) {
+	if _, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{}); !errors.Is(err, ErrInvalidHTTPConfig) {
+		t.Errorf("Expected ErrInvalidHTTPConfig for empty URL, got %v", err)
+	}
+	if _, err := NewHTTPBatchWriter(HTTPBatchWriterConfig{URL: "http://x", Method: "BAD METHOD"}); !errors.Is(err, ErrInvalidHTTPConfig) {
+		t.Errorf("Expected ErrInvalidHTTPConfig for bad method, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Errorf("Expected 2s, got %v", got)
+	}
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("Expected ~1m from HTTP date, got %v", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("Expected 0 for invalid value, got %v", got)
+	}
+}
+
+// ============================================================================
+// BODY BUILDER TESTS
+// ============================================================================
+
+func TestBodyBuilders(t *testing.T) {
+	ts := time.Unix(1700000000, 500000000)
+	records := []BatchRecord{
+		{Time: ts, Data: []byte(`{"level":"INFO","message":"json record"}`)},
+		{Time: ts, Data: []byte(`plain text record`)},
+	}
+
+	tests := []struct {
+		name    string
+		builder BodyBuilder
+		check   func(t *testing.T, body []byte)
+	}{
+		{
+			name:    "json array",
+			builder: JSONArrayBody(),
+			check: func(t *testing.T, body []byte) {
+				var docs []map[string]any
+				if err := json.Unmarshal(body, &docs); err != nil {
+					t.Fatalf("Invalid JSON array: %v", err)
+				}
+				if docs[0]["message"] != "json record" || docs[1]["message"] != "plain text record" {
+					t.Errorf("Unexpected docs: %v", docs)
+				}
+			},
+		},
+		{
+			name:    "elasticsearch bulk",
+			builder: ElasticsearchBulkBody("logs-app"),
+			check: func(t *testing.T, body []byte) {
+				lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+				if len(lines) != 4 {
+					t.Fatalf("Expected 4 bulk lines, got %d: %q", len(lines), body)
+				}
+				if lines[0] != `{"index":{"_index":"logs-app"}}` {
+					t.Errorf("Unexpected action line: %s", lines[0])
+				}
+				if !strings.Contains(lines[3], "plain text record") {
+					t.Errorf("Unexpected document line: %s", lines[3])
+				}
+			},
+		},
+		{
+			name:    "loki",
+			builder: LokiBody(map[string]string{"app": "api"}),
+			check: func(t *testing.T, body []byte) {
+				var payload struct {
+					Streams []struct {
+						Stream map[string]string `json:"stream"`
+						Values [][2]string       `json:"values"`
+					} `json:"streams"`
+				}
+				if err := json.Unmarshal(body, &payload); err != nil {
+					t.Fatalf("Invalid Loki payload: %v", err)
+				}
+				stream := payload.Streams[0]
+				if stream.Stream["app"] != "api" || len(stream.Values) != 2 {
+					t.Errorf("Unexpected stream: %+v", stream)
+				}
+				if stream.Values[0][0] != "1700000000500000000" {
+					t.Errorf("Expected nanosecond timestamp, got %s", stream.Values[0][0])
+				}
+			},
+		},
+		{
+			name:    "splunk hec",
+			builder: SplunkHECBody(SplunkHECConfig{SourceType: "_json", Index: "main"}),
+			check: func(t *testing.T, body []byte) {
+				dec := json.NewDecoder(bytes.NewReader(body))
+				var events []map[string]any
+				for dec.More() {
+					var event map[string]any
+					if err := dec.Decode(&event); err != nil {
+						t.Fatalf("Invalid HEC event: %v", err)
+					}
+					events = append(events, event)
+				}
+				if len(events) != 2 || events[0]["time"] != 1700000000.5 || events[0]["index"] != "main" {
+					t.Errorf("Unexpected events: %v", events)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.builder.Build(records)
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			tt.check(t, body)
+		})
+	}
+}
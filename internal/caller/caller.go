@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // GetCaller returns the caller information at the specified depth
@@ -19,3 +20,23 @@ func GetCaller(callerDepth int, fullPath bool) string {
 
 	return fmt.Sprintf("%s:%d", file, line)
 }
+
+// Outside returns the first stack frame, starting skip frames above the
+// caller of Outside, whose function is not in the package or module path
+// given by prefix. Frames from _test.go files are always accepted so that
+// a package's own tests report their call sites.
+func Outside(skip int, prefix string) (file string, line int, function string) {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, prefix+".") || strings.HasPrefix(frame.Function, prefix+"/")
+		if !internal || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File, frame.Line, frame.Function
+		}
+		if !more {
+			return "", 0, ""
+		}
+	}
+}
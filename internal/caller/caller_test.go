@@ -83,3 +83,17 @@ func TestGetCallerConsistency(t *testing.T) {
 		t.Errorf("GetCaller should return 'filename:line' format, got: %q", result)
 	}
 }
+
+func outsideHelper() (string, int, string) {
+	return Outside(0, "github.com/cybergodev/dd/internal/caller")
+}
+
+func TestOutside(t *testing.T) {
+	file, line, function := outsideHelper()
+	if !strings.HasSuffix(file, "caller_test.go") || line == 0 {
+		t.Errorf("Outside() = %s:%d, want a frame in caller_test.go", file, line)
+	}
+	if !strings.HasSuffix(function, "outsideHelper") {
+		t.Errorf("Outside() function = %q, want outsideHelper (test files are never skipped)", function)
+	}
+}
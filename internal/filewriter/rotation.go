@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -166,6 +167,54 @@ func GetBackupPath(basePath string, index int, compress bool) string {
 	return filepath.Join(dir, filename)
 }
 
+// ListBackups returns the backups of basePath, compressed or not, ordered
+// from oldest to newest. When a backup exists in both forms (compression
+// still in progress) the uncompressed file is returned.
+func ListBackups(basePath string) []string {
+	dir := filepath.Dir(basePath)
+	baseName := filepath.Base(basePath)
+	ext := filepath.Ext(baseName)
+	prefix := strings.TrimSuffix(baseName, ext) + "_" + strings.TrimPrefix(ext, ".")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	byIndex := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix+"_") {
+			continue
+		}
+
+		var index int
+		if _, err := fmt.Sscanf(name, prefix+"_%d"+ext, &index); err != nil || index <= 0 {
+			continue
+		}
+		switch name {
+		case filepath.Base(GetBackupPath(basePath, index, false)):
+			byIndex[index] = filepath.Join(dir, name)
+		case filepath.Base(GetBackupPath(basePath, index, true)):
+			if _, ok := byIndex[index]; !ok {
+				byIndex[index] = filepath.Join(dir, name)
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	paths := make([]string, len(indexes))
+	for i, index := range indexes {
+		paths[i] = byIndex[index]
+	}
+	return paths
+}
+
 func CompressFile(filePath string) error {
 	src, err := os.Open(filePath)
 	if err != nil {
@@ -414,3 +414,25 @@ func TestFindNextBackupIndex(t *testing.T) {
 		t.Errorf("FindNextBackupIndex() with compressed backup 1 = %d, want 2", index)
 	}
 }
+
+func TestListBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	basePath := filepath.Join(tmpDir, "app.log")
+
+	for _, name := range []string{"app_log_2.log.gz", "app_log_10.log", "app_log_1.log", "app_log_2.log", "other_log_3.log", "app_log_x.log"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := ListBackups(basePath)
+	want := []string{"app_log_1.log", "app_log_2.log", "app_log_10.log"}
+	if len(got) != len(want) {
+		t.Fatalf("ListBackups() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if filepath.Base(got[i]) != want[i] {
+			t.Errorf("ListBackups()[%d] = %s, want %s", i, filepath.Base(got[i]), want[i])
+		}
+	}
+}
@@ -148,10 +148,12 @@ func TestLevelToString(t *testing.T) {
 		level types.LogLevel
 		want  string
 	}{
+		{types.LevelTrace, "TRACE"},
 		{types.LevelDebug, "DEBUG"},
 		{types.LevelInfo, "INFO"},
 		{types.LevelWarn, "WARN"},
 		{types.LevelError, "ERROR"},
+		{types.LevelPanic, "PANIC"},
 		{types.LevelFatal, "FATAL"},
 		{types.LogLevel(-1), "UNKNOWN"},
 		{types.LogLevel(99), "UNKNOWN"},
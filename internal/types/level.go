@@ -0,0 +1,166 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LogLevel represents the severity level of a log message. Built-in levels
+// are spaced apart so that custom levels can be registered between them.
+type LogLevel int8
+
+const (
+	LevelTrace LogLevel = -10
+	LevelDebug LogLevel = 0
+	LevelInfo  LogLevel = 10
+	LevelWarn  LogLevel = 20
+	LevelError LogLevel = 30
+	LevelPanic LogLevel = 40
+	LevelFatal LogLevel = 50
+)
+
+// ErrInvalidLevel is returned for levels that are neither built in nor
+// registered
+var ErrInvalidLevel = errors.New("invalid log level")
+
+// ANSI escape sequences used to colorize level labels
+const (
+	ColorReset = "\033[0m"
+)
+
+type levelDef struct {
+	name  string
+	color string
+}
+
+var builtinLevels = map[LogLevel]levelDef{
+	LevelTrace: {"TRACE", "\033[90m"},
+	LevelDebug: {"DEBUG", "\033[36m"},
+	LevelInfo:  {"INFO", "\033[32m"},
+	LevelWarn:  {"WARN", "\033[33m"},
+	LevelError: {"ERROR", "\033[31m"},
+	LevelPanic: {"PANIC", "\033[1;31m"},
+	LevelFatal: {"FATAL", "\033[1;31m"},
+}
+
+var (
+	registerMu sync.Mutex
+	// customLevels is replaced wholesale on registration so readers on the
+	// logging path never take a lock
+	customLevels atomic.Pointer[map[LogLevel]levelDef]
+)
+
+// RegisterLevel registers a custom level with the given name and ANSI color
+// sequence (may be empty). The level's value is its severity, so a level
+// between LevelInfo and LevelWarn sorts and filters between them.
+func RegisterLevel(level LogLevel, name, color string) error {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" || strings.ContainsAny(name, " \t\r\n[]") {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidLevel, name)
+	}
+	if _, ok := builtinLevels[level]; ok {
+		return fmt.Errorf("%w: %d is a built-in level", ErrInvalidLevel, level)
+	}
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	current := loadCustomLevels()
+	if _, ok := current[level]; ok {
+		return fmt.Errorf("%w: %d is already registered", ErrInvalidLevel, level)
+	}
+	if _, ok := lookupName(name, current); ok || name == "WARNING" {
+		return fmt.Errorf("%w: name %q is already in use", ErrInvalidLevel, name)
+	}
+
+	next := make(map[LogLevel]levelDef, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	next[level] = levelDef{name: name, color: color}
+	customLevels.Store(&next)
+	return nil
+}
+
+func loadCustomLevels() map[LogLevel]levelDef {
+	if m := customLevels.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+func (l LogLevel) def() (levelDef, bool) {
+	if d, ok := builtinLevels[l]; ok {
+		return d, true
+	}
+	d, ok := loadCustomLevels()[l]
+	return d, ok
+}
+
+// IsValid reports whether l is a built-in or registered level
+func (l LogLevel) IsValid() bool {
+	_, ok := l.def()
+	return ok
+}
+
+func (l LogLevel) String() string {
+	if d, ok := l.def(); ok {
+		return d.name
+	}
+	return "UNKNOWN"
+}
+
+// Color returns the ANSI color sequence of the level, or "" if it has none
+func (l LogLevel) Color() string {
+	d, _ := l.def()
+	return d.color
+}
+
+// MarshalText encodes the level as its name
+func (l LogLevel) MarshalText() ([]byte, error) {
+	d, ok := l.def()
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidLevel, l)
+	}
+	return []byte(d.name), nil
+}
+
+// UnmarshalText decodes a level name as accepted by ParseLevel
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ParseLevel returns the level with the given case-insensitive name,
+// including registered custom levels. "warning" is accepted for WARN.
+func ParseLevel(name string) (LogLevel, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if upper == "WARNING" {
+		return LevelWarn, nil
+	}
+	if level, ok := lookupName(upper, loadCustomLevels()); ok {
+		return level, nil
+	}
+	return LevelInfo, fmt.Errorf("%w: %q", ErrInvalidLevel, name)
+}
+
+func lookupName(name string, custom map[LogLevel]levelDef) (LogLevel, bool) {
+	for level, d := range builtinLevels {
+		if d.name == name {
+			return level, true
+		}
+	}
+	for level, d := range custom {
+		if d.name == name {
+			return level, true
+		}
+	}
+	return LevelInfo, false
+}
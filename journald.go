@@ -0,0 +1,243 @@
+package dd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// maxJournalFieldName is the longest field name journald accepts.
+const maxJournalFieldName = 64
+
+// JournaldWriterConfig configures a JournaldWriter.
+type JournaldWriterConfig struct {
+	// SocketPath defaults to DefaultJournaldSocket.
+	SocketPath string
+	// Identifier is sent as SYSLOG_IDENTIFIER (defaults to the program name).
+	Identifier string
+	// Fields are added to every entry. Keys are normalized like Field keys.
+	Fields map[string]string
+	// MaxDatagramSize is the largest entry sent as a plain datagram; larger
+	// entries are passed to journald in a sealed memfd.
+	MaxDatagramSize int
+}
+
+// JournaldWriter sends entries to systemd-journald using its native
+// protocol, so level, caller and structured fields become indexed journal
+// fields instead of plain text:
+//
+//	MESSAGE=<message>
+//	PRIORITY=<syslog priority mapped from the level>
+//	CODE_FILE, CODE_LINE, CODE_FUNC=<call site>
+//	<FIELD KEY IN UPPER CASE>=<value>
+//
+// Attach it to the Logger directly so it receives entries through
+// WriteEntry. Plain Write calls are sent as MESSAGE with PRIORITY 6 (info).
+type JournaldWriter struct {
+	addr        *net.UnixAddr
+	conn        *net.UnixConn
+	common      []byte // pre-encoded SYSLOG_IDENTIFIER and static fields
+	maxDatagram int
+	closed      atomic.Bool
+}
+
+// NewJournaldWriter creates a JournaldWriter. It fails if the journal
+// socket does not exist, so callers can fall back to another writer on
+// hosts without systemd.
+func NewJournaldWriter(config JournaldWriterConfig) (*JournaldWriter, error) {
+	if config.SocketPath == "" {
+		config.SocketPath = DefaultJournaldSocket
+	}
+	if config.Identifier == "" {
+		config.Identifier = filepath.Base(os.Args[0])
+	}
+	if config.MaxDatagramSize <= 0 {
+		config.MaxDatagramSize = DefaultJournaldDatagramSize
+	}
+
+	if _, err := os.Stat(config.SocketPath); err != nil {
+		return nil, fmt.Errorf("journald socket unavailable: %w", err)
+	}
+
+	conn, err := newJournaldConn()
+	if err != nil {
+		return nil, err
+	}
+
+	common := appendJournalField(nil, "SYSLOG_IDENTIFIER", config.Identifier)
+	keys := make([]string, 0, len(config.Fields))
+	for k := range config.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if name := journalFieldName(k); name != "" {
+			common = appendJournalField(common, name, config.Fields[k])
+		}
+	}
+
+	return &JournaldWriter{
+		addr:        &net.UnixAddr{Name: config.SocketPath, Net: "unixgram"},
+		conn:        conn,
+		common:      common,
+		maxDatagram: config.MaxDatagramSize,
+	}, nil
+}
+
+// Write sends p as the MESSAGE of an informational entry.
+func (jw *JournaldWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	buf := appendJournalField(nil, "MESSAGE", string(bytes.TrimSuffix(p, []byte{'\n'})))
+	buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(journalPriority(LevelInfo)))
+	if err := jw.send(append(buf, jw.common...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry sends entry with its level, call site and fields.
+func (jw *JournaldWriter) WriteEntry(entry *Entry) error {
+	buf := appendJournalField(nil, "MESSAGE", entry.Message)
+	buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(journalPriority(entry.Level)))
+	if entry.File != "" {
+		buf = appendJournalField(buf, "CODE_FILE", entry.File)
+		buf = appendJournalField(buf, "CODE_LINE", strconv.Itoa(entry.Line))
+	}
+	if entry.Function != "" {
+		buf = appendJournalField(buf, "CODE_FUNC", entry.Function)
+	}
+	buf = append(buf, jw.common...)
+
+	for _, field := range entry.Fields {
+		name := journalFieldName(field.Key)
+		if name == "" || field.Value == nil {
+			continue
+		}
+		if reservedJournalFields[name] {
+			name = "FIELD_" + name
+		}
+		buf = appendJournalField(buf, name, fmt.Sprint(field.Value))
+	}
+
+	return jw.send(buf)
+}
+
+// send writes one entry, falling back to a memfd when it is too large for
+// a datagram.
+func (jw *JournaldWriter) send(payload []byte) error {
+	if jw.closed.Load() {
+		return ErrWriterClosed
+	}
+
+	if len(payload) <= jw.maxDatagram {
+		_, _, err := jw.conn.WriteMsgUnix(payload, nil, jw.addr)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+			return fmt.Errorf("journald send failed: %w", err)
+		}
+	}
+
+	if err := jw.sendFD(payload); err != nil {
+		return fmt.Errorf("journald send failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the socket.
+func (jw *JournaldWriter) Close() error {
+	if !jw.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return jw.conn.Close()
+}
+
+// journalPriority maps a level to a syslog priority. Custom levels between
+// INFO and WARN map to notice; other custom levels take the priority of the
+// nearest built-in level below them.
+func journalPriority(level LogLevel) int {
+	switch {
+	case level < LevelInfo:
+		return 7 // debug
+	case level == LevelInfo:
+		return 6 // info
+	case level < LevelWarn:
+		return 5 // notice
+	case level < LevelError:
+		return 4 // warning
+	case level < LevelPanic:
+		return 3 // err
+	default:
+		return 2 // crit
+	}
+}
+
+// reservedJournalFields are set by JournaldWriter itself; Field keys that
+// normalize to one of them are prefixed with FIELD_.
+var reservedJournalFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+	"SYSLOG_IDENTIFIER": true,
+}
+
+// journalFieldName converts key to a valid journal field name: upper-case
+// ASCII letters, digits and underscores, not starting with an underscore
+// (reserved for trusted fields) or a digit, at most 64 characters.
+// It returns "" if nothing usable remains.
+func journalFieldName(key string) string {
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			name = append(name, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			name = append(name, c)
+		default:
+			name = append(name, '_')
+		}
+	}
+
+	name = bytes.TrimLeft(name, "_")
+	if len(name) == 0 {
+		return ""
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = append([]byte("F_"), name...)
+	}
+	if len(name) > maxJournalFieldName {
+		name = name[:maxJournalFieldName]
+	}
+	return string(name)
+}
+
+// appendJournalField encodes one field. Values containing a newline use
+// the binary form: name, newline, 64-bit little-endian length, value.
+func appendJournalField(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+	} else {
+		buf = append(buf, '\n')
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+		buf = append(buf, value...)
+	}
+	return append(buf, '\n')
+}
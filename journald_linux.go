@@ -0,0 +1,109 @@
+//go:build linux
+
+package dd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	mfdCloexec       = 0x1
+	mfdAllowSealing  = 0x2
+	fcntlAddSeals    = 1024 + 9 // F_LINUX_SPECIFIC_BASE + 9
+	sealSeal         = 0x1
+	sealShrink       = 0x2
+	sealGrow         = 0x4
+	sealWrite        = 0x8
+	journalMemfdName = "dd-journal"
+)
+
+// memfdCreateTrap is the memfd_create syscall number, which the syscall
+// package does not export.
+var memfdCreateTrap = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+func newJournaldConn() (*net.UnixConn, error) {
+	// An unbound socket: each datagram is addressed explicitly, so a
+	// journald restart does not leave us connected to a stale socket.
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journald socket: %w", err)
+	}
+	return conn, nil
+}
+
+// sendFD writes payload to a sealed memfd and passes its descriptor to
+// journald, which reads the entry from it. Where memfd is unavailable an
+// unlinked file in /dev/shm is used instead.
+func (jw *JournaldWriter) sendFD(payload []byte) error {
+	file, err := journalPayloadFile(payload)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rights := syscall.UnixRights(int(file.Fd()))
+	if _, _, err := jw.conn.WriteMsgUnix(nil, rights, jw.addr); err != nil {
+		return err
+	}
+	return nil
+}
+
+func journalPayloadFile(payload []byte) (*os.File, error) {
+	if file, err := memfdCreate(journalMemfdName); err == nil {
+		if _, err := file.Write(payload); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("memfd write failed: %w", err)
+		}
+		_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), fcntlAddSeals,
+			sealSeal|sealShrink|sealGrow|sealWrite)
+		if errno != 0 {
+			file.Close()
+			return nil, fmt.Errorf("memfd seal failed: %w", errno)
+		}
+		return file, nil
+	}
+
+	file, err := os.CreateTemp("/dev/shm", "dd-journal-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal payload file: %w", err)
+	}
+	_ = os.Remove(file.Name())
+	if _, err := file.Write(payload); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("journal payload write failed: %w", err)
+	}
+	return file, nil
+}
+
+func memfdCreate(name string) (*os.File, error) {
+	trap, ok := memfdCreateTrap[runtime.GOARCH]
+	if !ok {
+		return nil, syscall.ENOSYS
+	}
+	namePtr, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(namePtr)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	return os.NewFile(fd, name), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package dd
+
+import "net"
+
+func newJournaldConn() (*net.UnixConn, error) {
+	return nil, ErrJournaldUnsupported
+}
+
+func (jw *JournaldWriter) sendFD(payload []byte) error {
+	return ErrJournaldUnsupported
+}
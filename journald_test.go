@@ -0,0 +1,225 @@
+//go:build linux
+
+package dd
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// JOURNALD WRITER TESTS
+// ============================================================================
+
+// journalStandIn is a unixgram socket standing in for journald
+type journalStandIn struct {
+	path string
+	conn *net.UnixConn
+}
+
+func newJournalStandIn(t *testing.T) *journalStandIn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &journalStandIn{path: path, conn: conn}
+}
+
+// receive reads one entry, following a passed file descriptor if present,
+// and decodes it into fields.
+func (j *journalStandIn) receive(t *testing.T) (fields map[string]string, viaFD bool) {
+	t.Helper()
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_ = j.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, oobn, _, _, err := j.conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("ReadMsgUnix failed: %v", err)
+	}
+
+	payload := buf[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("Invalid control message: %v", err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil || len(fds) != 1 {
+			t.Fatalf("Expected one passed fd: %v", err)
+		}
+		file := os.NewFile(uintptr(fds[0]), "journal-payload")
+		defer file.Close()
+		if payload, err = io.ReadAll(io.NewSectionReader(file, 0, 1<<30)); err != nil {
+			t.Fatalf("Reading passed fd failed: %v", err)
+		}
+		viaFD = true
+	}
+	return parseJournalPayload(t, payload), viaFD
+}
+
+func parseJournalPayload(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		nl := strings.IndexByte(string(data), '\n')
+		if nl < 0 {
+			t.Fatalf("Unterminated field in %q", data)
+		}
+		line := string(data[:nl])
+		if key, value, ok := strings.Cut(line, "="); ok {
+			fields[key] = value
+			data = data[nl+1:]
+			continue
+		}
+		size := int(binary.LittleEndian.Uint64(data[nl+1:]))
+		start := nl + 1 + 8
+		fields[line] = string(data[start : start+size])
+		data = data[start+size+1:]
+	}
+	return fields
+}
+
+func TestJournaldWriterEntry(t *testing.T) {
+	journal := newJournalStandIn(t)
+	jw, err := NewJournaldWriter(JournaldWriterConfig{
+		SocketPath: journal.path,
+		Identifier: "dd-test",
+		Fields:     map[string]string{"service.version": "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("NewJournaldWriter failed: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{jw}
+	config.SecurityConfig = &SecurityConfig{SensitiveFilter: NewBasicSensitiveDataFilter()}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.WarnWith("login password=hunter22", String("user_id", "42"), Int("http.status", 401), Any("message", "dup"))
+	_, _, line, _ := runtime.Caller(0)
+
+	fields, viaFD := journal.receive(t)
+	if viaFD {
+		t.Error("Small entries should be sent as a datagram")
+	}
+	checks := map[string]string{
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "dd-test",
+		"SERVICE_VERSION":   "1.2.3",
+		"USER_ID":           "42",
+		"HTTP_STATUS":       "401",
+		"FIELD_MESSAGE":     "dup",
+		"CODE_LINE":         strconv.Itoa(line - 1),
+	}
+	for key, want := range checks {
+		if fields[key] != want {
+			t.Errorf("%s = %q, want %q", key, fields[key], want)
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journald_test.go") {
+		t.Errorf("CODE_FILE should name the call site, got %q", fields["CODE_FILE"])
+	}
+	if !strings.HasSuffix(fields["CODE_FUNC"], "TestJournaldWriterEntry") {
+		t.Errorf("CODE_FUNC should name the calling function, got %q", fields["CODE_FUNC"])
+	}
+	if strings.Contains(fields["MESSAGE"], "hunter22") || !strings.HasPrefix(fields["MESSAGE"], "login ") {
+		t.Errorf("MESSAGE should be filtered, got %q", fields["MESSAGE"])
+	}
+}
+
+func TestJournaldWriterMultilineAndPlainWrite(t *testing.T) {
+	journal := newJournalStandIn(t)
+	jw, err := NewJournaldWriter(JournaldWriterConfig{SocketPath: journal.path})
+	if err != nil {
+		t.Fatalf("NewJournaldWriter failed: %v", err)
+	}
+	defer jw.Close()
+
+	if err := jw.WriteEntry(&Entry{Level: LevelError, Message: "panic:\n  at main.go"}); err != nil {
+		t.Fatalf("WriteEntry failed: %v", err)
+	}
+	fields, _ := journal.receive(t)
+	if fields["MESSAGE"] != "panic:\n  at main.go" || fields["PRIORITY"] != "3" {
+		t.Errorf("Unexpected multi-line entry: %q", fields)
+	}
+
+	if _, err := jw.Write([]byte("plain line\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	fields, _ = journal.receive(t)
+	if fields["MESSAGE"] != "plain line" || fields["PRIORITY"] != "6" {
+		t.Errorf("Unexpected plain entry: %q", fields)
+	}
+	if fields["SYSLOG_IDENTIFIER"] != filepath.Base(os.Args[0]) {
+		t.Errorf("Identifier should default to the program name, got %q", fields["SYSLOG_IDENTIFIER"])
+	}
+}
+
+func TestJournaldWriterLargePayloadViaFD(t *testing.T) {
+	journal := newJournalStandIn(t)
+	jw, err := NewJournaldWriter(JournaldWriterConfig{SocketPath: journal.path, MaxDatagramSize: 64})
+	if err != nil {
+		t.Fatalf("NewJournaldWriter failed: %v", err)
+	}
+	defer jw.Close()
+
+	large := strings.Repeat("x", 4096)
+	if err := jw.WriteEntry(&Entry{Level: LevelInfo, Message: large}); err != nil {
+		t.Fatalf("WriteEntry failed: %v", err)
+	}
+
+	fields, viaFD := journal.receive(t)
+	if !viaFD {
+		t.Fatal("Large entries should be passed as a file descriptor")
+	}
+	if fields["MESSAGE"] != large {
+		t.Errorf("Expected full message via fd, got %d bytes", len(fields["MESSAGE"]))
+	}
+}
+
+func TestJournaldWriterUnavailable(t *testing.T) {
+	if _, err := NewJournaldWriter(JournaldWriterConfig{SocketPath: filepath.Join(t.TempDir(), "missing.sock")}); err == nil {
+		t.Error("Expected an error when the journal socket does not exist")
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":               "USER_ID",
+		"http.status":           "HTTP_STATUS",
+		"_private":              "PRIVATE",
+		"2fa":                   "F_2FA",
+		"---":                   "",
+		strings.Repeat("a", 80): strings.Repeat("A", 64),
+	}
+	for key, want := range tests {
+		if got := journalFieldName(key); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestJournalPriority(t *testing.T) {
+	tests := map[LogLevel]int{LevelDebug: 7, LevelInfo: 6, LevelWarn: 4, LevelError: 3, LevelFatal: 2}
+	for level, want := range tests {
+		if got := journalPriority(level); got != want {
+			t.Errorf("journalPriority(%v) = %d, want %d", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package dd
+
+import "github.com/cybergodev/dd/internal/types"
+
+// RegisterLevel registers a custom level such as NOTICE or AUDIT. The level
+// value is its severity: it is filtered and sorted against the built-in
+// levels, which are spaced ten apart. color is an ANSI escape sequence used
+// when LoggerConfig.Color is set, and may be empty. Levels should be
+// registered once during program start-up, before they are logged.
+//
+//	const LevelNotice = dd.LevelInfo + 5
+//	_ = dd.RegisterLevel(LevelNotice, "NOTICE", "\033[34m")
+//	logger.Log(LevelNotice, "quota almost reached")
+func RegisterLevel(level LogLevel, name, color string) error {
+	return types.RegisterLevel(level, name, color)
+}
+
+// ParseLevel returns the level with the given case-insensitive name,
+// including custom levels. LogLevel also implements encoding.TextMarshaler
+// and encoding.TextUnmarshaler using the same names.
+func ParseLevel(name string) (LogLevel, error) {
+	return types.ParseLevel(name)
+}
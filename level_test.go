@@ -0,0 +1,147 @@
+package dd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// LEVEL TESTS
+// ============================================================================
+
+func TestTraceLevel(t *testing.T) {
+	var buf bytes.Buffer
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&buf}
+	config.Level = LevelDebug
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Trace("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("TRACE should be filtered at DEBUG, got: %q", buf.String())
+	}
+
+	if err := logger.SetLevel(LevelTrace); err != nil {
+		t.Fatalf("SetLevel(LevelTrace) failed: %v", err)
+	}
+	logger.TraceWith("frame", Int("len", 12))
+	if !strings.Contains(buf.String(), "[TRACE] frame len=12") {
+		t.Errorf("Expected TRACE record, got: %q", buf.String())
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	const levelNotice = LevelInfo + 5
+	// The level registry is global, so tolerate a previous run with -count.
+	if !levelNotice.IsValid() {
+		if err := RegisterLevel(levelNotice, "notice", "\033[34m"); err != nil {
+			t.Fatalf("RegisterLevel failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		level LogLevel
+		name2 string
+	}{
+		{"duplicate level", levelNotice, "OTHER"},
+		{"duplicate name", LevelInfo + 6, "Notice"},
+		{"built-in level", LevelWarn, "ALARM"},
+		{"built-in name", LevelInfo + 7, "error"},
+		{"empty name", LevelInfo + 8, " "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterLevel(tt.level, tt.name2, ""); !errors.Is(err, ErrInvalidLevel) {
+				t.Errorf("Expected ErrInvalidLevel, got %v", err)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&buf}
+	config.Color = true
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Log(levelNotice, "quota almost reached")
+	if !strings.Contains(buf.String(), "\033[34m[NOTICE]\033[0m quota almost reached") {
+		t.Errorf("Expected colored NOTICE record, got: %q", buf.String())
+	}
+
+	buf.Reset()
+	_ = logger.SetLevel(LevelWarn)
+	logger.Log(levelNotice, "filtered")
+	if buf.Len() != 0 {
+		t.Errorf("NOTICE should be filtered at WARN, got: %q", buf.String())
+	}
+
+	if level, err := ParseLevel(" Notice "); err != nil || level != levelNotice {
+		t.Errorf("ParseLevel(notice) = %v, %v", level, err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want LogLevel
+	}{
+		{"trace", LevelTrace},
+		{"DEBUG", LevelDebug},
+		{"info", LevelInfo},
+		{"warn", LevelWarn},
+		{"Warning", LevelWarn},
+		{"error", LevelError},
+		{"panic", LevelPanic},
+		{"fatal", LevelFatal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if err != nil || got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, %v; want %v", tt.name, got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseLevel("verbose"); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("Expected ErrInvalidLevel for unknown name, got %v", err)
+	}
+}
+
+func TestLevelTextMarshalling(t *testing.T) {
+	type settings struct {
+		Level LogLevel `json:"level"`
+	}
+
+	data, err := json.Marshal(settings{Level: LevelWarn})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != `{"level":"WARN"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	var s settings
+	if err := json.Unmarshal([]byte(`{"level":"trace"}`), &s); err != nil || s.Level != LevelTrace {
+		t.Errorf("Unmarshal = %v, %v", s.Level, err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &s); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("Expected ErrInvalidLevel, got %v", err)
+	}
+	if _, err := json.Marshal(settings{Level: LogLevel(99)}); err == nil {
+		t.Error("Expected an error marshalling an unknown level")
+	}
+}
@@ -3,11 +3,14 @@ package dd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/cybergodev/dd/internal/types"
 )
@@ -27,10 +30,12 @@ type LogLevel = types.LogLevel
 
 // Log level constants
 const (
+	LevelTrace = types.LevelTrace
 	LevelDebug = types.LevelDebug
 	LevelInfo  = types.LevelInfo
 	LevelWarn  = types.LevelWarn
 	LevelError = types.LevelError
+	LevelPanic = types.LevelPanic
 	LevelFatal = types.LevelFatal
 )
 
@@ -48,8 +53,23 @@ type Logger struct {
 	fatalHandler FatalHandler
 	formatter    *MessageFormatter
 
-	// Mutable state protected by synchronization
-	writers        []io.Writer
+	// Write failure handling (set once during initialization)
+	errorHandler     ErrorHandler
+	fallbackWriter   io.Writer
+	failureThreshold int
+	retryInterval    time.Duration
+
+	// Flight recorder for records below the level (set once during initialization)
+	recorder      *RingBufferWriter
+	recorderLevel LogLevel
+
+	metrics loggerMetrics
+
+	// Mutable state protected by synchronization. The writer list is
+	// replaced, never modified, under mu; the read lock is only held while
+	// registering in-flight writes, not during writer I/O.
+	writers        atomic.Pointer[[]writerEntry]
+	nextWriterID   int // guarded by mu
 	mu             sync.RWMutex
 	securityConfig atomic.Value // *SecurityConfig
 
@@ -73,32 +93,38 @@ func New(configs ...*LoggerConfig) (*Logger, error) {
 		return nil, fmt.Errorf("invalid logger configuration: %w", err)
 	}
 
+	l := newLogger(config)
+	for _, writer := range config.Writers {
+		if err := l.AddWriter(writer); err != nil {
+			l.cancel()
+			return nil, fmt.Errorf("failed to add writer: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// newLogger builds a Logger without writers from a validated configuration.
+func newLogger(config *LoggerConfig) *Logger {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	l := &Logger{
-		callerDepth:  DefaultCallerDepth,
-		fatalHandler: config.FatalHandler,
-		formatter:    newMessageFormatter(config),
-		writers:      make([]io.Writer, 0, len(config.Writers)),
-		ctx:          ctx,
-		cancel:       cancel,
+		callerDepth:      DefaultCallerDepth,
+		fatalHandler:     config.FatalHandler,
+		formatter:        newMessageFormatter(config),
+		errorHandler:     config.ErrorHandler,
+		fallbackWriter:   config.FallbackWriter,
+		failureThreshold: config.WriterFailureThreshold,
+		retryInterval:    config.WriterRetryInterval,
+		recorder:         config.FlightRecorder,
+		recorderLevel:    config.FlightRecorderLevel,
+		ctx:              ctx,
+		cancel:           cancel,
 	}
 
 	// Set atomic values
 	l.level.Store(int32(config.Level))
 	l.securityConfig.Store(config.SecurityConfig)
-
-	// Add writers if provided
-	if config.Writers != nil {
-		for _, writer := range config.Writers {
-			if err := l.AddWriter(writer); err != nil {
-				cancel()
-				return nil, fmt.Errorf("failed to add writer: %w", err)
-			}
-		}
-	}
-
-	return l, nil
+	return l
 }
 
 // GetLevel returns the current log level (thread-safe).
@@ -110,7 +136,7 @@ func (l *Logger) GetLevel() LogLevel {
 
 // SetLevel atomically sets the log level (thread-safe).
 func (l *Logger) SetLevel(level LogLevel) error {
-	if level < LevelDebug || level > LevelFatal {
+	if !level.IsValid() {
 		return ErrInvalidLevel
 	}
 	l.level.Store(int32(level))
@@ -151,15 +177,18 @@ func (l *Logger) AddWriter(writer io.Writer) error {
 		return ErrLoggerClosed
 	}
 
-	if len(l.writers) >= MaxWriterCount {
+	current := l.loadWriters()
+	if len(current) >= MaxWriterCount {
 		return ErrMaxWritersExceeded
 	}
 
-	l.writers = append(l.writers, writer)
+	l.storeWriters(append(current[:len(current):len(current)], l.newEntryLocked(writer)))
 	return nil
 }
 
 // RemoveWriter removes a writer from the logger in a thread-safe manner.
+// It returns once in-flight writes to the writer have finished, so the
+// writer can be closed afterwards.
 func (l *Logger) RemoveWriter(writer io.Writer) error {
 	if writer == nil {
 		return ErrNilWriter
@@ -170,25 +199,143 @@ func (l *Logger) RemoveWriter(writer io.Writer) error {
 	}
 
 	l.mu.Lock()
-	defer l.mu.Unlock()
 
 	// Check again after acquiring lock
 	if l.closed.Load() {
+		l.mu.Unlock()
 		return ErrLoggerClosed
 	}
 
-	for i, w := range l.writers {
-		if w == writer {
-			// Remove writer by swapping with last element and truncating
-			l.writers[i] = l.writers[len(l.writers)-1]
-			l.writers = l.writers[:len(l.writers)-1]
+	current := l.loadWriters()
+	for i, entry := range current {
+		if entry.writer == writer {
+			l.storeWriters(slices.Delete(slices.Clone(current), i, i+1))
+			l.mu.Unlock()
+			entry.inflight.Wait()
 			return nil
 		}
 	}
+	l.mu.Unlock()
 
 	return fmt.Errorf("writer not found")
 }
 
+// loadWriters returns the current writer list. The returned slice is never
+// modified; changes replace it under l.mu.
+func (l *Logger) loadWriters() []writerEntry {
+	if writers := l.writers.Load(); writers != nil {
+		return *writers
+	}
+	return nil
+}
+
+// newEntryLocked wraps w with the next writer ID; the caller must hold l.mu.
+func (l *Logger) newEntryLocked(w io.Writer) writerEntry {
+	entry := newWriterEntry(l.nextWriterID, w)
+	l.nextWriterID++
+	return entry
+}
+
+// storeWriters replaces the writer list; the caller must hold l.mu.
+func (l *Logger) storeWriters(writers []writerEntry) {
+	l.writers.Store(&writers)
+}
+
+// acquireWriters returns the current writers with an in-flight write
+// registered on each; the caller must call inflight.Done for every entry.
+func (l *Logger) acquireWriters() []writerEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	writers := l.loadWriters()
+	for _, entry := range writers {
+		entry.inflight.Add(1)
+	}
+	return writers
+}
+
+// waitInflight waits for in-flight writes to the given writers.
+func waitInflight(writers []writerEntry) {
+	for _, entry := range writers {
+		entry.inflight.Wait()
+	}
+}
+
+// Sync flushes and fsyncs every writer, descending into MultiWriter and
+// BufferedWriter. All failures are returned joined.
+func (l *Logger) Sync() error {
+	var errs []error
+	for _, entry := range l.acquireWriters() {
+		if err := syncWriter(entry.writer); err != nil {
+			errs = append(errs, err)
+		}
+		entry.inflight.Done()
+	}
+	return errors.Join(errs...)
+}
+
+// SyncContext is like Sync but gives up when ctx is done. Writers that are
+// still syncing keep running in the background.
+func (l *Logger) SyncContext(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Sync()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// swapConfig atomically replaces the level, security configuration and a
+// subset of writers. It waits for in-flight writes to finish, so the
+// removed writers can be closed safely once it returns.
+func (l *Logger) swapConfig(level LogLevel, secConfig *SecurityConfig, remove, add []io.Writer) error {
+	if !level.IsValid() {
+		return ErrInvalidLevel
+	}
+
+	l.mu.Lock()
+
+	if l.closed.Load() {
+		l.mu.Unlock()
+		return ErrLoggerClosed
+	}
+
+	current := l.loadWriters()
+	writers := make([]writerEntry, 0, len(current)+len(add))
+	var removed []writerEntry
+	for _, entry := range current {
+		if slices.Contains(remove, entry.writer) {
+			removed = append(removed, entry)
+		} else {
+			writers = append(writers, entry)
+		}
+	}
+	for _, w := range add {
+		writers = append(writers, l.newEntryLocked(w))
+	}
+
+	if len(writers) > MaxWriterCount {
+		l.mu.Unlock()
+		return ErrMaxWritersExceeded
+	}
+
+	l.storeWriters(writers)
+	l.level.Store(int32(level))
+	if secConfig == nil {
+		secConfig = DefaultSecurityConfig()
+	}
+	l.securityConfig.Store(secConfig)
+	l.mu.Unlock()
+
+	waitInflight(removed)
+	return nil
+}
+
 // Close closes the logger and all associated resources (thread-safe).
 func (l *Logger) Close() error {
 	var closeErr error
@@ -198,10 +345,14 @@ func (l *Logger) Close() error {
 		l.cancel()
 
 		l.mu.Lock()
-		defer l.mu.Unlock()
+		writers := l.loadWriters()
+		l.writers.Store(nil)
+		l.mu.Unlock()
+		waitInflight(writers)
 
 		// Close all closeable writers (except standard streams)
-		for _, writer := range l.writers {
+		for _, entry := range writers {
+			writer := entry.writer
 			if closer, ok := writer.(io.Closer); ok {
 				// Don't close standard streams (stdout, stderr, stdin)
 				if writer != os.Stdout && writer != os.Stderr && writer != os.Stdin {
@@ -211,9 +362,6 @@ func (l *Logger) Close() error {
 				}
 			}
 		}
-
-		// Clear writers slice
-		l.writers = nil
 	})
 
 	return closeErr
@@ -223,10 +371,17 @@ func (l *Logger) Close() error {
 func (l *Logger) shouldLog(level LogLevel) bool {
 	// Optimize: check level first (most common filter), then closed state
 	currentLevel := LogLevel(l.level.Load())
-	if level < currentLevel || level < LevelDebug || level > LevelFatal {
+	if !level.IsValid() {
+		return false
+	}
+	if level < currentLevel && (l.recorder == nil || level < l.recorderLevel) {
 		return false
 	}
-	return !l.closed.Load()
+	if l.closed.Load() {
+		l.metrics.dropped.Add(1)
+		return false
+	}
+	return true
 }
 
 // getSecurityConfig returns the current security configuration
@@ -240,45 +395,47 @@ func (l *Logger) getSecurityConfig() *SecurityConfig {
 // Log logs a message at the specified level
 func (l *Logger) Log(level LogLevel, args ...any) {
 	if !l.shouldLog(level) {
+		if level == LevelPanic {
+			panic(fmt.Sprint(args...))
+		}
 		return
 	}
 
-	msg := fmt.Sprint(args...)
+	msg := l.filterMessage(fmt.Sprint(args...))
 	message := l.formatter.formatMessage(level, l.callerDepth, msg)
-	l.writeMessage(l.applySecurity(message))
-
-	if level == LevelFatal {
-		l.handleFatal()
-	}
+	l.output(l.limitMessage(message), record{level: level, msg: msg})
+	l.finish(level, msg)
 }
 
 // Logf logs a formatted message at the specified level
 func (l *Logger) Logf(level LogLevel, format string, args ...any) {
 	if !l.shouldLog(level) {
+		if level == LevelPanic {
+			panic(fmt.Sprintf(format, args...))
+		}
 		return
 	}
 
-	message := l.formatter.formatMessagef(level, l.callerDepth, format, args...)
-	l.writeMessage(l.applySecurity(message))
-
-	if level == LevelFatal {
-		l.handleFatal()
-	}
+	msg := l.filterMessage(fmt.Sprintf(format, args...))
+	message := l.formatter.formatMessage(level, l.callerDepth, msg)
+	l.output(l.limitMessage(message), record{level: level, msg: msg})
+	l.finish(level, msg)
 }
 
 // LogWith logs a structured message with fields at the specified level
 func (l *Logger) LogWith(level LogLevel, msg string, fields ...Field) {
 	if !l.shouldLog(level) {
+		if level == LevelPanic {
+			panic(msg)
+		}
 		return
 	}
 
+	msg = l.filterMessage(msg)
 	processedFields := l.processFields(fields)
 	message := l.formatter.formatMessageWith(level, l.callerDepth, msg, processedFields)
-	l.writeMessage(l.applySecurity(message))
-
-	if level == LevelFatal {
-		l.handleFatal()
-	}
+	l.output(l.limitMessage(message), record{level: level, msg: msg, fields: processedFields})
+	l.finish(level, msg)
 }
 
 // processFields processes and filters structured fields
@@ -295,17 +452,21 @@ func (l *Logger) processFields(fields []Field) []Field {
 	// Apply security filtering to field values
 	filtered := make([]Field, len(fields))
 	for i, field := range fields {
-		filtered[i] = Field{
-			Key:   field.Key,
-			Value: secConfig.SensitiveFilter.FilterFieldValue(field.Key, field.Value),
+		value, changed := secConfig.SensitiveFilter.filterField(field.Key, field.Value)
+		filtered[i] = Field{Key: field.Key, Value: value}
+		if changed {
+			l.metrics.redactions.Add(1)
 		}
 	}
 
 	return filtered
 }
 
-// applySecurity applies security measures to the final message
-func (l *Logger) applySecurity(message string) string {
+// filterMessage applies the size limit and the sensitive data filter to a
+// log message. It runs once per record; the formatted line, the Entry and
+// the panic value are all built from its result, and field values are
+// filtered separately by processFields.
+func (l *Logger) filterMessage(message string) string {
 	secConfig := l.getSecurityConfig()
 	if secConfig == nil {
 		return message
@@ -318,12 +479,31 @@ func (l *Logger) applySecurity(message string) string {
 
 	// Apply sensitive data filtering
 	if secConfig.SensitiveFilter != nil && secConfig.SensitiveFilter.IsEnabled() {
-		message = secConfig.SensitiveFilter.Filter(message)
+		filtered := secConfig.SensitiveFilter.Filter(message)
+		if filtered != message {
+			l.metrics.redactions.Add(1)
+		}
+		message = filtered
 	}
 
 	return sanitizeControlChars(message)
 }
 
+// limitMessage bounds a formatted line, whose message and fields have
+// already been filtered, and removes control characters.
+func (l *Logger) limitMessage(message string) string {
+	secConfig := l.getSecurityConfig()
+	if secConfig == nil {
+		return message
+	}
+
+	// Fields are not covered by the message limit, so bound the line as well
+	if limit := secConfig.MaxMessageSize; limit > 0 && len(message) > limit {
+		message = message[:limit] + "..."
+	}
+	return sanitizeControlChars(message)
+}
+
 // sanitizeControlChars removes control characters from the message
 func sanitizeControlChars(message string) string {
 	if len(message) == 0 {
@@ -360,8 +540,21 @@ func isControlChar(c byte) bool {
 	return c == '\x00' || (c < 32 && c != '\n' && c != '\r' && c != '\t') || c == 127
 }
 
+// finish runs the side effects of PANIC and FATAL records after they have
+// been written.
+func (l *Logger) finish(level LogLevel, msg string) {
+	switch level {
+	case LevelPanic:
+		_ = l.Sync()
+		panic(msg)
+	case LevelFatal:
+		l.handleFatal()
+	}
+}
+
 // handleFatal handles fatal log messages
 func (l *Logger) handleFatal() {
+	_ = l.Sync()
 	_ = l.Close()
 	if l.fatalHandler != nil {
 		l.fatalHandler()
@@ -370,9 +563,32 @@ func (l *Logger) handleFatal() {
 	}
 }
 
-// writeMessage writes a message to all configured writers
-func (l *Logger) writeMessage(message string) {
-	if l.closed.Load() || len(message) == 0 {
+// output routes a formatted message. Records below the logger level only
+// reach the flight recorder; ERROR and FATAL records are preceded by a dump
+// of the flight recorder.
+func (l *Logger) output(message string, rec record) {
+	if rec.level < LogLevel(l.level.Load()) {
+		if l.recorder != nil && len(message) > 0 {
+			_, _ = l.recorder.Write(append([]byte(message), '\n'))
+		}
+		return
+	}
+
+	l.metrics.countLevel(rec.level)
+	if rec.level >= LevelError && l.recorder != nil {
+		l.DumpFlightRecorder()
+	}
+	l.writeMessage(l.formatter.colorizeLevel(message, rec.level), rec)
+}
+
+// writeMessage writes a message to all configured writers. EntryWriters
+// receive the structured form of rec instead of the formatted line.
+func (l *Logger) writeMessage(message string, rec record) {
+	if len(message) == 0 {
+		return
+	}
+	if l.closed.Load() {
+		l.metrics.dropped.Add(1)
 		return
 	}
 
@@ -397,50 +613,88 @@ func (l *Logger) writeMessage(message string) {
 	buf = append(buf, message...)
 	buf = append(buf, '\n')
 
-	// Get writers list
-	l.mu.RLock()
-	writerCount := len(l.writers)
-	if writerCount == 0 {
-		l.mu.RUnlock()
-		return
+	l.writeRecord(buf, &rec)
+}
+
+// writeRecord writes one newline-terminated record to every writer. When
+// rec is nil, EntryWriters receive buf through Write as well.
+func (l *Logger) writeRecord(buf []byte, rec *record) {
+	// Writes are registered as in flight, so writers removed by
+	// RemoveWriter or a config reload are only closed once they finish.
+	var failures []writeFailure
+	var structured *Entry
+
+	writers := l.acquireWriters()
+	for _, entry := range writers {
+		if !entry.health.allow(l.retryInterval) {
+			entry.inflight.Done()
+			failures = append(failures, writeFailure{writer: entry.writer})
+			continue
+		}
+
+		if structured == nil && entry.entryWriter != nil && rec != nil {
+			structured = l.newEntry(*rec)
+		}
+		if err := writeEntry(entry, buf, structured); err != nil {
+			entry.health.recordFailure(err, l.failureThreshold, l.retryInterval)
+			failures = append(failures, writeFailure{writer: entry.writer, err: err})
+			continue
+		}
+		entry.health.recordSuccess(len(buf))
 	}
+	delivered := len(writers) > len(failures)
 
-	// Optimize: single writer fast path
-	if writerCount == 1 {
-		w := l.writers[0]
-		l.mu.RUnlock()
-		_, _ = w.Write(buf)
-		return
+	if !delivered && rec != nil {
+		l.metrics.dropped.Add(1)
 	}
+	if len(failures) > 0 {
+		l.handleWriteFailures(buf, failures)
+	}
+}
 
-	// Multiple writers: copy slice then release lock
-	writers := make([]io.Writer, writerCount)
-	copy(writers, l.writers)
-	l.mu.RUnlock()
+// writeEntry writes one record to a single writer and releases its
+// in-flight registration. EntryWriters receive structured when it is set.
+func writeEntry(entry writerEntry, buf []byte, structured *Entry) error {
+	defer entry.inflight.Done()
 
-	// Write to all writers
-	for _, writer := range writers {
-		_, _ = writer.Write(buf)
+	var err error
+	start := time.Now()
+	if entry.entryWriter != nil && structured != nil {
+		err = entry.entryWriter.WriteEntry(structured)
+	} else {
+		var n int
+		n, err = entry.writer.Write(buf)
+		if err == nil && n < len(buf) {
+			err = io.ErrShortWrite
+		}
 	}
+	entry.health.latency.observe(time.Since(start))
+	return err
 }
 
 // Convenience logging methods
+func (l *Logger) Trace(args ...any) { l.Log(LevelTrace, args...) }
 func (l *Logger) Debug(args ...any) { l.Log(LevelDebug, args...) }
 func (l *Logger) Info(args ...any)  { l.Log(LevelInfo, args...) }
 func (l *Logger) Warn(args ...any)  { l.Log(LevelWarn, args...) }
 func (l *Logger) Error(args ...any) { l.Log(LevelError, args...) }
+func (l *Logger) Panic(args ...any) { l.Log(LevelPanic, args...) }
 func (l *Logger) Fatal(args ...any) { l.Log(LevelFatal, args...) }
 
+func (l *Logger) Tracef(format string, args ...any) { l.Logf(LevelTrace, format, args...) }
 func (l *Logger) Debugf(format string, args ...any) { l.Logf(LevelDebug, format, args...) }
 func (l *Logger) Infof(format string, args ...any)  { l.Logf(LevelInfo, format, args...) }
 func (l *Logger) Warnf(format string, args ...any)  { l.Logf(LevelWarn, format, args...) }
 func (l *Logger) Errorf(format string, args ...any) { l.Logf(LevelError, format, args...) }
+func (l *Logger) Panicf(format string, args ...any) { l.Logf(LevelPanic, format, args...) }
 func (l *Logger) Fatalf(format string, args ...any) { l.Logf(LevelFatal, format, args...) }
 
+func (l *Logger) TraceWith(msg string, fields ...Field) { l.LogWith(LevelTrace, msg, fields...) }
 func (l *Logger) DebugWith(msg string, fields ...Field) { l.LogWith(LevelDebug, msg, fields...) }
 func (l *Logger) InfoWith(msg string, fields ...Field)  { l.LogWith(LevelInfo, msg, fields...) }
 func (l *Logger) WarnWith(msg string, fields ...Field)  { l.LogWith(LevelWarn, msg, fields...) }
 func (l *Logger) ErrorWith(msg string, fields ...Field) { l.LogWith(LevelError, msg, fields...) }
+func (l *Logger) PanicWith(msg string, fields ...Field) { l.LogWith(LevelPanic, msg, fields...) }
 func (l *Logger) FatalWith(msg string, fields ...Field) { l.LogWith(LevelFatal, msg, fields...) }
 
 // Global default logger management
@@ -454,17 +708,7 @@ func Default() *Logger {
 	defaultOnce.Do(func() {
 		logger, err := New(nil)
 		if err != nil {
-			// Create a minimal fallback logger that always works
-			ctx, cancel := context.WithCancel(context.Background())
-			logger = &Logger{
-				callerDepth: DefaultCallerDepth,
-				formatter:   newMessageFormatter(DefaultConfig()),
-				writers:     []io.Writer{os.Stderr},
-				ctx:         ctx,
-				cancel:      cancel,
-			}
-			logger.level.Store(int32(LevelInfo))
-			logger.securityConfig.Store(DefaultSecurityConfig())
+			logger = newFallbackLogger()
 		}
 		defaultLogger.Store(logger)
 	})
@@ -472,6 +716,17 @@ func Default() *Logger {
 	return defaultLogger.Load()
 }
 
+// newFallbackLogger creates a minimal stderr logger that always works. It
+// goes through the same constructor as New, so write failures are still
+// handled by the fallback writer and circuit breaker.
+func newFallbackLogger() *Logger {
+	config := DefaultConfig()
+	_ = config.Validate()
+	logger := newLogger(config)
+	_ = logger.AddWriter(os.Stderr)
+	return logger
+}
+
 // SetDefault sets the default global logger (thread-safe)
 func SetDefault(logger *Logger) {
 	if logger != nil {
@@ -480,14 +735,19 @@ func SetDefault(logger *Logger) {
 }
 
 // Package-level convenience functions
+func Trace(args ...any)                 { Default().Log(LevelTrace, args...) }
 func Debug(args ...any)                 { Default().Log(LevelDebug, args...) }
 func Info(args ...any)                  { Default().Log(LevelInfo, args...) }
 func Warn(args ...any)                  { Default().Log(LevelWarn, args...) }
 func Error(args ...any)                 { Default().Log(LevelError, args...) }
+func Panic(args ...any)                 { Default().Log(LevelPanic, args...) }
 func Fatal(args ...any)                 { Default().Log(LevelFatal, args...) }
+func Tracef(format string, args ...any) { Default().Logf(LevelTrace, format, args...) }
 func Debugf(format string, args ...any) { Default().Logf(LevelDebug, format, args...) }
 func Infof(format string, args ...any)  { Default().Logf(LevelInfo, format, args...) }
 func Warnf(format string, args ...any)  { Default().Logf(LevelWarn, format, args...) }
 func Errorf(format string, args ...any) { Default().Logf(LevelError, format, args...) }
+func Panicf(format string, args ...any) { Default().Logf(LevelPanic, format, args...) }
 func Fatalf(format string, args ...any) { Default().Logf(LevelFatal, format, args...) }
 func SetLevel(level LogLevel)           { _ = Default().SetLevel(level) }
+func Sync() error                       { return Default().Sync() }
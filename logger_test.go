@@ -2,12 +2,16 @@ package dd
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // ============================================================================
@@ -258,6 +262,69 @@ func TestLoggerWriterManagement(t *testing.T) {
 	}
 }
 
+// blockingWriter blocks in Write until released
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	b.once.Do(func() { close(b.started) })
+	<-b.release
+	return len(p), nil
+}
+
+func TestWriterManagementDuringSlowWrite(t *testing.T) {
+	slow := newBlockingWriter()
+	config := DefaultConfig()
+	config.Writers = []io.Writer{slow}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	go logger.Info("stuck")
+	<-slow.started
+
+	// A slow writer must not block changes to the writer list
+	done := make(chan error, 1)
+	go func() {
+		var buf bytes.Buffer
+		if err := logger.AddWriter(&buf); err != nil {
+			done <- err
+			return
+		}
+		done <- logger.RemoveWriter(&buf)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AddWriter/RemoveWriter failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("AddWriter/RemoveWriter blocked behind a slow write")
+	}
+
+	// Removing the slow writer itself waits for its in-flight write
+	removed := make(chan error, 1)
+	go func() { removed <- logger.RemoveWriter(slow) }()
+	select {
+	case <-removed:
+		t.Fatal("RemoveWriter returned while a write was in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(slow.release)
+	if err := <-removed; err != nil {
+		t.Errorf("RemoveWriter failed: %v", err)
+	}
+}
+
 func TestLoggerClose(t *testing.T) {
 	var buf bytes.Buffer
 	config := DefaultConfig()
@@ -558,6 +625,293 @@ func TestManyFields(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// SYNC TESTS
+// ============================================================================
+
+// syncCounter records how many times Sync was called
+type syncCounter struct {
+	bytes.Buffer
+	syncs atomic.Int32
+	err   error
+}
+
+func (s *syncCounter) Sync() error {
+	s.syncs.Add(1)
+	return s.err
+}
+
+func TestLoggerSync(t *testing.T) {
+	inner := &syncCounter{}
+	bw, err := NewBufferedWriter(inner, 64*1024)
+	if err != nil {
+		t.Fatalf("Failed to create buffered writer: %v", err)
+	}
+	direct := &syncCounter{}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{NewMultiWriter(bw, direct)}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("buffered message")
+
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if !strings.Contains(inner.String(), "buffered message") {
+		t.Errorf("Sync should flush buffered writers nested in MultiWriter, got: %q", inner.String())
+	}
+	if inner.syncs.Load() != 1 {
+		t.Errorf("Expected writer behind BufferedWriter to be synced once, got %d", inner.syncs.Load())
+	}
+	if direct.syncs.Load() != 1 {
+		t.Errorf("Expected direct writer to be synced once, got %d", direct.syncs.Load())
+	}
+}
+
+func TestLoggerSyncError(t *testing.T) {
+	failing := &syncCounter{err: errors.New("disk gone")}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{failing, &syncCounter{}}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	if err := logger.Sync(); err == nil || !strings.Contains(err.Error(), "disk gone") {
+		t.Errorf("Expected sync error to be reported, got: %v", err)
+	}
+}
+
+func TestLoggerSyncFileWriter(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "sync.log")
+	config, err := DefaultConfig().WithFileOnly(logFile, FileWriterConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("durable")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(data), "durable") {
+		t.Errorf("Expected synced data in file, got: %s", data)
+	}
+}
+
+// blockingSyncer blocks in Sync until released
+type blockingSyncer struct {
+	release chan struct{}
+}
+
+func (b *blockingSyncer) Write(p []byte) (int, error) { return len(p), nil }
+func (b *blockingSyncer) Sync() error {
+	<-b.release
+	return nil
+}
+
+func TestLoggerSyncContext(t *testing.T) {
+	blocker := &blockingSyncer{release: make(chan struct{})}
+	defer close(blocker.release)
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{blocker}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := logger.SyncContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestFatalSyncsBeforeExit(t *testing.T) {
+	inner := &syncCounter{}
+	bw, err := NewBufferedWriter(inner, 64*1024)
+	if err != nil {
+		t.Fatalf("Failed to create buffered writer: %v", err)
+	}
+
+	var flushedAtExit string
+	config := DefaultConfig()
+	config.Writers = []io.Writer{NewMultiWriter(bw)}
+	config.FatalHandler = func() { flushedAtExit = inner.String() }
+
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Fatal("fatal message")
+
+	if !strings.Contains(flushedAtExit, "fatal message") {
+		t.Errorf("Fatal record should be flushed before the fatal handler runs, got: %q", flushedAtExit)
+	}
+	if inner.syncs.Load() == 0 {
+		t.Error("Expected writers to be synced before exit")
+	}
+}
+
+// ============================================================================
+// WRITER FAILURE TESTS
+// ============================================================================
+
+func TestWriterErrorHandlerAndFallback(t *testing.T) {
+	var fallback bytes.Buffer
+	var mu sync.Mutex
+	var handled []error
+
+	primary := &failingWriter{failAfter: 1}
+	config := DefaultConfig()
+	config.Writers = []io.Writer{primary}
+	config.FallbackWriter = &fallback
+	config.ErrorHandler = func(w io.Writer, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if w != primary {
+			t.Errorf("ErrorHandler got unexpected writer %T", w)
+		}
+		handled = append(handled, err)
+	}
+
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("first")
+	logger.Info("second")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 {
+		t.Fatalf("Expected 1 handled error, got %d", len(handled))
+	}
+	if strings.Contains(fallback.String(), "first") {
+		t.Errorf("Successful record should not reach fallback, got: %s", fallback.String())
+	}
+	if !strings.Contains(fallback.String(), "second") {
+		t.Errorf("Failed record should reach fallback, got: %s", fallback.String())
+	}
+
+	stats := logger.WriterStats()
+	if len(stats) != 1 || stats[0].Writes != 1 || stats[0].Failures != 1 || stats[0].LastError == nil {
+		t.Errorf("Unexpected writer stats: %+v", stats)
+	}
+}
+
+// toggleWriter fails while broken is set and counts write attempts
+type toggleWriter struct {
+	broken   atomic.Bool
+	attempts atomic.Int32
+	bytes.Buffer
+}
+
+func (tw *toggleWriter) Write(p []byte) (int, error) {
+	tw.attempts.Add(1)
+	if tw.broken.Load() {
+		return 0, errors.New("broken pipe")
+	}
+	return tw.Buffer.Write(p)
+}
+
+func TestWriterCircuitBreaker(t *testing.T) {
+	var fallback bytes.Buffer
+	tw := &toggleWriter{}
+	tw.broken.Store(true)
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{tw}
+	config.FallbackWriter = &fallback
+	config.WriterFailureThreshold = 2
+	config.WriterRetryInterval = 50 * time.Millisecond
+
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	for i := 0; i < 5; i++ {
+		logger.Info("while broken")
+	}
+
+	if got := tw.attempts.Load(); got != 2 {
+		t.Errorf("Writer should be disabled after 2 failures, got %d attempts", got)
+	}
+	if got := strings.Count(fallback.String(), "while broken"); got != 5 {
+		t.Errorf("All 5 records should reach the fallback, got %d", got)
+	}
+	if stats := logger.WriterStats(); !stats[0].Disabled {
+		t.Errorf("Writer should be reported disabled, got %+v", stats[0])
+	}
+
+	tw.broken.Store(false)
+	time.Sleep(60 * time.Millisecond)
+
+	logger.Info("recovered")
+	logger.Info("recovered again")
+
+	if got := strings.Count(tw.String(), "recovered"); got != 2 {
+		t.Errorf("Writer should be re-enabled after retry interval, got %d records: %q", got, tw.String())
+	}
+	if stats := logger.WriterStats(); stats[0].Disabled || stats[0].ConsecutiveFailures != 0 {
+		t.Errorf("Writer should be healthy after recovery, got %+v", stats[0])
+	}
+}
+
+func TestWriterFailureDefaults(t *testing.T) {
+	config := DefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if config.FallbackWriter != os.Stderr {
+		t.Error("FallbackWriter should default to os.Stderr")
+	}
+	if config.WriterFailureThreshold != DefaultWriterFailureThreshold {
+		t.Errorf("Expected default threshold %d, got %d", DefaultWriterFailureThreshold, config.WriterFailureThreshold)
+	}
+	if config.WriterRetryInterval != DefaultWriterRetryInterval {
+		t.Errorf("Expected default retry interval %v, got %v", DefaultWriterRetryInterval, config.WriterRetryInterval)
+	}
+
+	// The default logger's fallback must keep the same failure handling
+	logger := newFallbackLogger()
+	defer logger.Close()
+	if logger.fallbackWriter != os.Stderr {
+		t.Error("Fallback logger should write failed records to os.Stderr")
+	}
+	if logger.failureThreshold != DefaultWriterFailureThreshold || logger.retryInterval != DefaultWriterRetryInterval {
+		t.Errorf("Fallback logger should use the default circuit breaker, got threshold %d, interval %v",
+			logger.failureThreshold, logger.retryInterval)
+	}
+	if writers := logger.loadWriters(); len(writers) != 1 || writers[0].writer != os.Stderr {
+		t.Errorf("Fallback logger should write to os.Stderr, got %d writers", len(writers))
+	}
+}
+
 // ============================================================================
 // SECURITY TESTS
 // ============================================================================
@@ -0,0 +1,266 @@
+package dd
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the write latency histogram.
+var latencyBuckets = [...]time.Duration{
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Stats is a point-in-time snapshot of a logger's counters.
+type Stats struct {
+	// Levels counts records written at each level
+	Levels map[LogLevel]uint64
+	// Dropped counts records that no writer accepted, including records
+	// logged after the logger was closed
+	Dropped uint64
+	// Redactions counts messages and field values changed by the
+	// sensitive data filter
+	Redactions uint64
+	// RedactionRules reports per-rule hits and validator rejections of the
+	// current sensitive data filter
+	RedactionRules []RuleStats
+	// WriteErrors is the sum of Failures over all writers
+	WriteErrors uint64
+	Writers     []WriterStats
+}
+
+// LatencyHistogram is a snapshot of write latencies. Counts[i] is the number
+// of writes that took at most Bounds[i] (and more than Bounds[i-1]); the last
+// element of Counts holds the writes slower than every bound.
+type LatencyHistogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+// latencyHistogram is a lock-free histogram over latencyBuckets.
+type latencyHistogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Uint64
+	sum    atomic.Int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *latencyHistogram) snapshot() LatencyHistogram {
+	snap := LatencyHistogram{
+		Bounds: latencyBuckets[:],
+		Counts: make([]uint64, len(h.counts)),
+		Sum:    time.Duration(h.sum.Load()),
+	}
+	for i := range h.counts {
+		snap.Counts[i] = h.counts[i].Load()
+		snap.Count += snap.Counts[i]
+	}
+	return snap
+}
+
+// loggerMetrics holds the counters behind Logger.Stats. Per-writer bytes,
+// failures and latency live in writerHealth.
+type loggerMetrics struct {
+	levels     [256]atomic.Uint64 // indexed by level + 128
+	dropped    atomic.Uint64
+	redactions atomic.Uint64
+}
+
+func (m *loggerMetrics) countLevel(level LogLevel) {
+	m.levels[int(level)+128].Add(1)
+}
+
+// Stats returns a snapshot of the logger's counters.
+func (l *Logger) Stats() Stats {
+	stats := Stats{
+		Levels:     make(map[LogLevel]uint64),
+		Dropped:    l.metrics.dropped.Load(),
+		Redactions: l.metrics.redactions.Load(),
+		Writers:    l.WriterStats(),
+	}
+	for i := range l.metrics.levels {
+		if n := l.metrics.levels[i].Load(); n > 0 {
+			stats.Levels[LogLevel(i-128)] = n
+		}
+	}
+	for _, w := range stats.Writers {
+		stats.WriteErrors += w.Failures
+	}
+	if secConfig := l.getSecurityConfig(); secConfig != nil && secConfig.SensitiveFilter != nil {
+		stats.RedactionRules = secConfig.SensitiveFilter.RuleStats()
+	}
+	return stats
+}
+
+// expvarLoggers maps names published by PublishExpvar to the logger they
+// currently report on.
+var (
+	expvarMu      sync.Mutex
+	expvarLoggers = make(map[string]*atomic.Pointer[Logger])
+)
+
+// PublishExpvar publishes Stats under name in the expvar registry, making
+// them available on /debug/vars. Publishing a name again, e.g. after
+// re-initialising the logger, switches the variable to this logger. It
+// returns ErrExpvarInUse if name was registered by other code.
+func (l *Logger) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if current, ok := expvarLoggers[name]; ok {
+		current.Store(l)
+		return nil
+	}
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w: %q", ErrExpvarInUse, name)
+	}
+
+	current := &atomic.Pointer[Logger]{}
+	current.Store(l)
+	expvarLoggers[name] = current
+	expvar.Publish(name, expvar.Func(func() any { return current.Load().Stats() }))
+	return nil
+}
+
+// MetricsHandler returns an http.Handler serving Stats in the Prometheus
+// text exposition format.
+func (l *Logger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		writePrometheus(bw, l.Stats())
+		_ = bw.Flush()
+	})
+}
+
+func writePrometheus(w *bufio.Writer, stats Stats) {
+	levels := make([]LogLevel, 0, len(stats.Levels))
+	for level := range stats.Levels {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+
+	promHeader(w, "dd_log_records_total", "counter", "Records written by level.")
+	for _, level := range levels {
+		fmt.Fprintf(w, "dd_log_records_total{level=\"%s\"} %d\n", promLabel(level.String()), stats.Levels[level])
+	}
+
+	promHeader(w, "dd_log_dropped_records_total", "counter", "Records that no writer accepted.")
+	fmt.Fprintf(w, "dd_log_dropped_records_total %d\n", stats.Dropped)
+	promHeader(w, "dd_log_redactions_total", "counter", "Messages and field values changed by the sensitive data filter.")
+	fmt.Fprintf(w, "dd_log_redactions_total %d\n", stats.Redactions)
+	rules := mergeRuleStats(stats.RedactionRules)
+	promHeader(w, "dd_redaction_hits_total", "counter", "Matches masked by redaction rule.")
+	for _, rs := range rules {
+		fmt.Fprintf(w, "dd_redaction_hits_total{rule=\"%s\"} %d\n", promLabel(rs.Name), rs.Hits)
+	}
+	promHeader(w, "dd_redaction_dry_run_hits_total", "counter", "Matches left unmasked by a redaction rule in dry-run mode.")
+	for _, rs := range rules {
+		fmt.Fprintf(w, "dd_redaction_dry_run_hits_total{rule=\"%s\"} %d\n", promLabel(rs.Name), rs.DryRunHits)
+	}
+	promHeader(w, "dd_redaction_rejected_total", "counter", "Candidate matches rejected by a rule's validator.")
+	for _, rs := range rules {
+		fmt.Fprintf(w, "dd_redaction_rejected_total{rule=\"%s\"} %d\n", promLabel(rs.Name), rs.Rejected)
+	}
+	promHeader(w, "dd_log_write_errors_total", "counter", "Failed writes across all writers.")
+	fmt.Fprintf(w, "dd_log_write_errors_total %d\n", stats.WriteErrors)
+
+	promHeader(w, "dd_writer_writes_total", "counter", "Successful writes by writer.")
+	for _, ws := range stats.Writers {
+		fmt.Fprintf(w, "dd_writer_writes_total{writer=\"%s\"} %d\n", promLabel(ws.Writer), ws.Writes)
+	}
+	promHeader(w, "dd_writer_failures_total", "counter", "Failed writes by writer.")
+	for _, ws := range stats.Writers {
+		fmt.Fprintf(w, "dd_writer_failures_total{writer=\"%s\"} %d\n", promLabel(ws.Writer), ws.Failures)
+	}
+	promHeader(w, "dd_writer_bytes_total", "counter", "Bytes accepted by writer.")
+	for _, ws := range stats.Writers {
+		fmt.Fprintf(w, "dd_writer_bytes_total{writer=\"%s\"} %d\n", promLabel(ws.Writer), ws.Bytes)
+	}
+	promHeader(w, "dd_writer_disabled", "gauge", "Whether the writer is disabled by the circuit breaker.")
+	for _, ws := range stats.Writers {
+		disabled := 0
+		if ws.Disabled {
+			disabled = 1
+		}
+		fmt.Fprintf(w, "dd_writer_disabled{writer=\"%s\"} %d\n", promLabel(ws.Writer), disabled)
+	}
+
+	promHeader(w, "dd_writer_write_duration_seconds", "histogram", "Write latency by writer.")
+	for _, ws := range stats.Writers {
+		label := promLabel(ws.Writer)
+		var cumulative uint64
+		for i, count := range ws.Latency.Counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(ws.Latency.Bounds) {
+				le = promFloat(ws.Latency.Bounds[i].Seconds())
+			}
+			fmt.Fprintf(w, "dd_writer_write_duration_seconds_bucket{writer=\"%s\",le=\"%s\"} %d\n", label, le, cumulative)
+		}
+		fmt.Fprintf(w, "dd_writer_write_duration_seconds_sum{writer=\"%s\"} %s\n", label, promFloat(ws.Latency.Sum.Seconds()))
+		fmt.Fprintf(w, "dd_writer_write_duration_seconds_count{writer=\"%s\"} %d\n", label, ws.Latency.Count)
+	}
+}
+
+// mergeRuleStats sums rules sharing a name so that every series is unique.
+// Rules added with AddPattern are reported as "unnamed".
+func mergeRuleStats(stats []RuleStats) []RuleStats {
+	merged := make([]RuleStats, 0, len(stats))
+	index := make(map[string]int, len(stats))
+	for _, rs := range stats {
+		if rs.Name == "" {
+			rs.Name = "unnamed"
+		}
+		if i, ok := index[rs.Name]; ok {
+			merged[i].Hits += rs.Hits
+			merged[i].DryRunHits += rs.DryRunHits
+			merged[i].Rejected += rs.Rejected
+			continue
+		}
+		index[rs.Name] = len(merged)
+		merged = append(merged, rs)
+	}
+	return merged
+}
+
+func promHeader(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var promLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promLabel(s string) string {
+	return promLabelReplacer.Replace(s)
+}
+
+func promFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,195 @@
+package dd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// METRICS TESTS
+// ============================================================================
+
+func TestLoggerStats(t *testing.T) {
+	var buf bytes.Buffer
+	failing := &failingWriter{}
+	config := DefaultConfig()
+	config.Level = LevelDebug
+	config.Writers = []io.Writer{&buf, failing}
+	config.FallbackWriter = io.Discard
+	config.SecurityConfig = SecureSecurityConfig()
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Debug("one")
+	logger.Info("two")
+	logger.Info("three")
+	logger.Trace("filtered")
+	logger.InfoWith("login", String("private_key", "hunter2"))
+	logger.Error("password=hunter2")
+
+	stats := logger.Stats()
+	if stats.Levels[LevelDebug] != 1 || stats.Levels[LevelInfo] != 3 || stats.Levels[LevelError] != 1 {
+		t.Errorf("Unexpected level counts: %v", stats.Levels)
+	}
+	if _, ok := stats.Levels[LevelTrace]; ok {
+		t.Error("Filtered records should not be counted")
+	}
+	if stats.Redactions != 2 {
+		t.Errorf("Expected 2 redactions, got %d", stats.Redactions)
+	}
+	if len(stats.RedactionRules) != logger.getSecurityConfig().SensitiveFilter.PatternCount() {
+		t.Errorf("Expected per-rule redaction stats, got %v", stats.RedactionRules)
+	}
+	if stats.WriteErrors != 5 {
+		t.Errorf("Expected 5 write errors, got %d", stats.WriteErrors)
+	}
+	if stats.Writers[0].Bytes != uint64(buf.Len()) {
+		t.Errorf("Expected %d bytes for the buffer writer, got %d", buf.Len(), stats.Writers[0].Bytes)
+	}
+	if stats.Writers[0].Latency.Count != 5 || len(stats.Writers[0].Latency.Counts) != len(stats.Writers[0].Latency.Bounds)+1 {
+		t.Errorf("Unexpected latency histogram: %+v", stats.Writers[0].Latency)
+	}
+	if stats.Dropped != 0 {
+		t.Errorf("Expected no drops while one writer works, got %d", stats.Dropped)
+	}
+
+	_ = logger.Close()
+	logger.Info("after close")
+	if got := logger.Stats().Dropped; got != 1 {
+		t.Errorf("Expected 1 dropped record after close, got %d", got)
+	}
+}
+
+func TestLoggerStatsDropsWhenAllWritersFail(t *testing.T) {
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&failingWriter{}}
+	config.FallbackWriter = io.Discard
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("lost")
+	if got := logger.Stats().Dropped; got != 1 {
+		t.Errorf("Expected 1 dropped record, got %d", got)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRecoverTestLogger(t, &buf)
+	defer logger.Close()
+	logger.Info("hello")
+	logger.Warn("careful")
+
+	rec := httptest.NewRecorder()
+	logger.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE dd_log_records_total counter",
+		`dd_log_records_total{level="INFO"} 1`,
+		`dd_log_records_total{level="WARN"} 1`,
+		"dd_log_dropped_records_total 0",
+		"# TYPE dd_writer_write_duration_seconds histogram",
+		`dd_writer_write_duration_seconds_bucket{writer="writer[0] (*bytes.Buffer)",le="+Inf"} 2`,
+		`dd_writer_write_duration_seconds_count{writer="writer[0] (*bytes.Buffer)"} 2`,
+		`dd_writer_bytes_total{writer="writer[0] (*bytes.Buffer)"} ` + strconv.Itoa(buf.Len()),
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestWriterLabelsStableAfterRemoval(t *testing.T) {
+	var first, second, third bytes.Buffer
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&first, &second}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	if err := logger.RemoveWriter(&first); err != nil {
+		t.Fatalf("RemoveWriter failed: %v", err)
+	}
+	if err := logger.AddWriter(&third); err != nil {
+		t.Fatalf("AddWriter failed: %v", err)
+	}
+
+	stats := logger.WriterStats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 writers, got %d", len(stats))
+	}
+	want := []string{"writer[1] (*bytes.Buffer)", "writer[2] (*bytes.Buffer)"}
+	for i, ws := range stats {
+		if ws.Writer != want[i] {
+			t.Errorf("Writer %d: expected label %q, got %q", i, want[i], ws.Writer)
+		}
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRecoverTestLogger(t, &buf)
+	defer logger.Close()
+	logger.Error("boom")
+
+	if err := logger.PublishExpvar("dd_test_stats"); err != nil {
+		t.Fatalf("PublishExpvar failed: %v", err)
+	}
+	var decoded struct {
+		Levels map[string]uint64
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("dd_test_stats").String()), &decoded); err != nil {
+		t.Fatalf("Failed to decode expvar: %v", err)
+	}
+	if decoded.Levels["ERROR"] != 1 {
+		t.Errorf("Expected ERROR=1 in expvar output, got %v", decoded.Levels)
+	}
+
+	// Publishing the name again switches it to the new logger
+	var buf2 bytes.Buffer
+	second := newRecoverTestLogger(t, &buf2)
+	defer second.Close()
+	second.Warn("careful")
+	if err := second.PublishExpvar("dd_test_stats"); err != nil {
+		t.Fatalf("Re-publishing failed: %v", err)
+	}
+	decoded.Levels = nil
+	if err := json.Unmarshal([]byte(expvar.Get("dd_test_stats").String()), &decoded); err != nil {
+		t.Fatalf("Failed to decode expvar: %v", err)
+	}
+	if decoded.Levels["WARN"] != 1 || decoded.Levels["ERROR"] != 0 {
+		t.Errorf("Expected the second logger's stats, got %v", decoded.Levels)
+	}
+}
+
+func TestPublishExpvarNameInUse(t *testing.T) {
+	const name = "dd_test_foreign"
+	if expvar.Get(name) == nil {
+		expvar.NewInt(name)
+	}
+
+	var buf bytes.Buffer
+	logger := newRecoverTestLogger(t, &buf)
+	defer logger.Close()
+	if err := logger.PublishExpvar(name); !errors.Is(err, ErrExpvarInUse) {
+		t.Errorf("Expected ErrExpvarInUse, got %v", err)
+	}
+}
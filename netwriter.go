@@ -0,0 +1,563 @@
+package dd
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Framing selects how records are delimited on the wire.
+type Framing int8
+
+const (
+	// FramingNewline terminates every record with '\n' (added if missing).
+	FramingNewline Framing = iota
+	// FramingLengthPrefix precedes every record with its length as a
+	// 4-byte big-endian unsigned integer.
+	FramingLengthPrefix
+)
+
+// NetWriterConfig configures a NetWriter.
+type NetWriterConfig struct {
+	// Network is one of "tcp", "udp", "unix" or "unixgram".
+	Network string
+	// Address is host:port for tcp/udp or a socket path for unix/unixgram.
+	Address string
+	// TLSConfig enables TLS (tcp only).
+	TLSConfig *tls.Config
+	// Framing selects newline or length-prefix record delimiting.
+	Framing Framing
+
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the exponential reconnect delay.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// BufferSize is the maximum number of bytes held while the endpoint
+	// is unreachable. Records beyond it are rejected with ErrBufferFull.
+	BufferSize int
+	// BufferPath, if set, keeps the outage buffer in a file so that
+	// undelivered records survive a restart.
+	BufferPath string
+}
+
+// NetWriter writes records to a stream or datagram socket. It dials lazily on
+// the first write, reconnects with exponential backoff, and buffers records
+// in memory or on disk while the endpoint is unreachable.
+//
+// Delivery is best effort: a write to a stream socket can succeed locally
+// after the peer has gone away, and such a record is lost.
+type NetWriter struct {
+	network      string
+	address      string
+	tlsConfig    *tls.Config
+	framing      Framing
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	buffer   outageBuffer
+	backoff  time.Duration
+	nextDial time.Time
+	closed   bool
+
+	dropped atomic.Uint64
+}
+
+// NewNetWriter validates config and creates a NetWriter. No connection is
+// made until the first write.
+func NewNetWriter(config NetWriterConfig) (*NetWriter, error) {
+	switch config.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+	case "udp", "udp4", "udp6", "unixgram":
+		if config.TLSConfig != nil {
+			return nil, fmt.Errorf("%w: TLS requires a tcp network", ErrInvalidNetwork)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNetwork, config.Network)
+	}
+	if config.Network == "unix" && config.TLSConfig != nil {
+		return nil, fmt.Errorf("%w: TLS requires a tcp network", ErrInvalidNetwork)
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("%w: empty address", ErrInvalidNetwork)
+	}
+	if config.Framing != FramingNewline && config.Framing != FramingLengthPrefix {
+		return nil, fmt.Errorf("%w: unknown framing %d", ErrInvalidNetwork, config.Framing)
+	}
+
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = DefaultDialTimeout
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultNetWriteTimeout
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultMinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(DefaultMaxBackoff, config.MinBackoff)
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultNetBufferSize
+	}
+
+	var buffer outageBuffer
+	if config.BufferPath != "" {
+		fb, err := newFileBuffer(config.BufferPath, config.BufferSize)
+		if err != nil {
+			return nil, err
+		}
+		buffer = fb
+	} else {
+		buffer = &memoryBuffer{limit: config.BufferSize}
+	}
+
+	return &NetWriter{
+		network:      config.Network,
+		address:      config.Address,
+		tlsConfig:    config.TLSConfig,
+		framing:      config.Framing,
+		dialTimeout:  config.DialTimeout,
+		writeTimeout: config.WriteTimeout,
+		minBackoff:   config.MinBackoff,
+		maxBackoff:   config.MaxBackoff,
+		buffer:       buffer,
+	}, nil
+}
+
+func (nw *NetWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	frame := nw.frame(p)
+
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	if nw.closed {
+		return 0, ErrWriterClosed
+	}
+
+	// Older buffered records go first to preserve ordering.
+	if err := nw.flushLocked(); err == nil {
+		if err := nw.sendLocked(frame); err == nil {
+			return len(p), nil
+		}
+	}
+
+	if err := nw.buffer.push(frame); err != nil {
+		nw.dropped.Add(1)
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// frame applies the configured framing to a single record.
+func (nw *NetWriter) frame(p []byte) []byte {
+	switch nw.framing {
+	case FramingLengthPrefix:
+		frame := make([]byte, 4+len(p))
+		binary.BigEndian.PutUint32(frame, uint32(len(p)))
+		copy(frame[4:], p)
+		return frame
+	default:
+		if p[len(p)-1] == '\n' {
+			return append([]byte(nil), p...)
+		}
+		frame := make([]byte, len(p)+1)
+		copy(frame, p)
+		frame[len(p)] = '\n'
+		return frame
+	}
+}
+
+// connectLocked returns the current connection, dialing if the backoff
+// window has passed.
+func (nw *NetWriter) connectLocked() (net.Conn, error) {
+	if nw.conn != nil {
+		return nw.conn, nil
+	}
+	if time.Now().Before(nw.nextDial) {
+		return nil, errBackoff
+	}
+
+	dialer := &net.Dialer{Timeout: nw.dialTimeout}
+	var conn net.Conn
+	var err error
+	if nw.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, nw.network, nw.address, nw.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(nw.network, nw.address)
+	}
+	if err != nil {
+		nw.scheduleRetryLocked()
+		return nil, err
+	}
+
+	nw.conn = conn
+	nw.backoff = 0
+	return conn, nil
+}
+
+func (nw *NetWriter) scheduleRetryLocked() {
+	if nw.backoff == 0 {
+		nw.backoff = nw.minBackoff
+	} else {
+		nw.backoff = min(nw.backoff*2, nw.maxBackoff)
+	}
+	nw.nextDial = time.Now().Add(nw.backoff)
+}
+
+func (nw *NetWriter) sendLocked(frame []byte) error {
+	conn, err := nw.connectLocked()
+	if err != nil {
+		return err
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(nw.writeTimeout))
+	if _, err := conn.Write(frame); err != nil {
+		_ = conn.Close()
+		nw.conn = nil
+		nw.scheduleRetryLocked()
+		return err
+	}
+	return nil
+}
+
+// flushLocked sends buffered records in order until the buffer is empty or
+// a send fails.
+func (nw *NetWriter) flushLocked() error {
+	if nw.buffer.len() == 0 {
+		return nil
+	}
+	// Leave the buffer alone until a reconnect can be attempted
+	if nw.conn == nil && time.Now().Before(nw.nextDial) {
+		return errBackoff
+	}
+	return nw.buffer.drain(nw.sendLocked)
+}
+
+// Sync tries to deliver buffered records, respecting the reconnect backoff.
+func (nw *NetWriter) Sync() error {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	if nw.closed {
+		return nil
+	}
+	if err := nw.flushLocked(); err != nil {
+		return fmt.Errorf("%d records still buffered: %w", nw.buffer.len(), err)
+	}
+	return nil
+}
+
+// Shutdown keeps retrying delivery of buffered records until the buffer is
+// empty or ctx is done, then closes the connection. Records left in a file
+// buffer are kept for the next start.
+func (nw *NetWriter) Shutdown(ctx context.Context) error {
+	for {
+		nw.mu.Lock()
+		if nw.closed {
+			nw.mu.Unlock()
+			return nil
+		}
+		err := nw.flushLocked()
+		wait := time.Until(nw.nextDial)
+		nw.mu.Unlock()
+
+		if err == nil {
+			break
+		}
+
+		timer := time.NewTimer(max(wait, time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			nw.mu.Lock()
+			pending := nw.buffer.len()
+			nw.mu.Unlock()
+			return errors.Join(
+				fmt.Errorf("%d records not delivered: %w", pending, ctx.Err()),
+				nw.Close(),
+			)
+		case <-timer.C:
+		}
+	}
+
+	return nw.Close()
+}
+
+// Close closes the connection without waiting for buffered records.
+func (nw *NetWriter) Close() error {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	if nw.closed {
+		return nil
+	}
+	nw.closed = true
+
+	var errs []error
+	if nw.conn != nil {
+		errs = append(errs, nw.conn.Close())
+		nw.conn = nil
+	}
+	errs = append(errs, nw.buffer.close())
+	return errors.Join(errs...)
+}
+
+// Connected reports whether the writer currently holds an open connection.
+func (nw *NetWriter) Connected() bool {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	return nw.conn != nil
+}
+
+// Buffered returns the number of records waiting for the endpoint.
+func (nw *NetWriter) Buffered() int {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	return nw.buffer.len()
+}
+
+// Dropped returns the number of records rejected because the buffer was full.
+func (nw *NetWriter) Dropped() uint64 {
+	return nw.dropped.Load()
+}
+
+var errBackoff = errors.New("reconnect backoff in effect")
+
+// outageBuffer holds framed records while the endpoint is unreachable.
+type outageBuffer interface {
+	push(frame []byte) error
+	// drain sends records oldest first, removing each one that send accepts,
+	// and stops at the first error.
+	drain(send func([]byte) error) error
+	len() int
+	close() error
+}
+
+type memoryBuffer struct {
+	frames [][]byte
+	size   int
+	limit  int
+}
+
+func (b *memoryBuffer) push(frame []byte) error {
+	if b.size+len(frame) > b.limit {
+		return ErrBufferFull
+	}
+	b.frames = append(b.frames, frame)
+	b.size += len(frame)
+	return nil
+}
+
+func (b *memoryBuffer) drain(send func([]byte) error) error {
+	for len(b.frames) > 0 {
+		if err := send(b.frames[0]); err != nil {
+			return err
+		}
+		b.size -= len(b.frames[0])
+		b.frames[0] = nil
+		b.frames = b.frames[1:]
+	}
+	b.frames = nil
+	return nil
+}
+
+func (b *memoryBuffer) len() int     { return len(b.frames) }
+func (b *memoryBuffer) close() error { return nil }
+
+// fileBuffer appends records to a file, each preceded by a 4-byte length.
+// Frame offsets are kept in memory, so a drain reads each record once.
+// After each drain the undelivered remainder is written to a temporary file
+// that replaces the buffer, so a crash never loses records.
+type fileBuffer struct {
+	path    string
+	file    *os.File
+	offsets []int64 // start of each frame's length prefix
+	end     int64   // end of the last complete frame
+	size    int
+	limit   int
+	reads   int // frames read back from the file
+}
+
+func newFileBuffer(path string, limit int) (*fileBuffer, error) {
+	securePath, err := validateAndSecurePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(securePath), DirPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create buffer directory: %w", err)
+	}
+
+	file, err := os.OpenFile(securePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, FilePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open buffer file: %w", err)
+	}
+
+	fb := &fileBuffer{path: securePath, file: file, limit: limit}
+	if err := fb.scan(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	// Cut off a torn frame left by a crash, or later frames would be
+	// appended behind it and could never be read back.
+	if info, err := file.Stat(); err == nil && info.Size() > fb.end {
+		if err := file.Truncate(fb.end); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to truncate buffer file: %w", err)
+		}
+	}
+	return fb, nil
+}
+
+// scan records the offset of every complete frame in the file.
+func (b *fileBuffer) scan() error {
+	r := bufio.NewReader(io.NewSectionReader(b.file, 0, 1<<62))
+	var prefix [4]byte
+	for {
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			break // end of file, or a truncated prefix from a crash
+		}
+		n := int(binary.BigEndian.Uint32(prefix[:]))
+		if skipped, err := r.Discard(n); err != nil {
+			if skipped < n {
+				break // truncated tail from a crash mid-write
+			}
+			return fmt.Errorf("failed to read buffer file: %w", err)
+		}
+		b.offsets = append(b.offsets, b.end)
+		b.end += int64(4 + n)
+		b.size += n
+	}
+	return nil
+}
+
+// encodeFrame returns frame with its 4-byte length prefix.
+func encodeFrame(frame []byte) []byte {
+	data := make([]byte, 0, 4+len(frame))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(frame)))
+	return append(data, frame...)
+}
+
+func (b *fileBuffer) push(frame []byte) error {
+	if b.size+len(frame) > b.limit {
+		return ErrBufferFull
+	}
+
+	record := encodeFrame(frame)
+	if _, err := b.file.Write(record); err != nil {
+		// Drop a partial write so the next frame starts on a boundary
+		_ = b.file.Truncate(b.end)
+		return fmt.Errorf("buffer write failed: %w", err)
+	}
+
+	b.offsets = append(b.offsets, b.end)
+	b.end += int64(len(record))
+	b.size += len(frame)
+	return nil
+}
+
+// readFrame reads the i-th buffered frame from the file.
+func (b *fileBuffer) readFrame(i int) ([]byte, error) {
+	next := b.end
+	if i+1 < len(b.offsets) {
+		next = b.offsets[i+1]
+	}
+	frame := make([]byte, next-b.offsets[i]-4)
+	if _, err := b.file.ReadAt(frame, b.offsets[i]+4); err != nil {
+		return nil, fmt.Errorf("failed to read buffer file: %w", err)
+	}
+	b.reads++
+	return frame, nil
+}
+
+func (b *fileBuffer) drain(send func([]byte) error) error {
+	sent := 0
+	var sendErr error
+	for i := range b.offsets {
+		var frame []byte
+		if frame, sendErr = b.readFrame(i); sendErr != nil {
+			break
+		}
+		if sendErr = send(frame); sendErr != nil {
+			break
+		}
+		sent++
+	}
+	if sent == 0 {
+		return sendErr
+	}
+
+	if err := b.discard(sent); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// discard atomically swaps the buffer file for one without the first n
+// frames.
+func (b *fileBuffer) discard(n int) error {
+	base := b.end
+	if n < len(b.offsets) {
+		base = b.offsets[n]
+	}
+
+	tmpPath := b.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to create buffer file: %w", err)
+	}
+	_, err = io.Copy(tmp, io.NewSectionReader(b.file, base, b.end-base))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, b.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to rewrite buffer file: %w", err)
+	}
+
+	file, err := os.OpenFile(b.path, os.O_RDWR|os.O_APPEND, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to reopen buffer file: %w", err)
+	}
+	_ = b.file.Close()
+	b.file = file
+
+	b.size -= int(base) - 4*n
+	b.end -= base
+	b.offsets = append(b.offsets[:0], b.offsets[n:]...)
+	for i := range b.offsets {
+		b.offsets[i] -= base
+	}
+	return nil
+}
+
+func (b *fileBuffer) len() int { return len(b.offsets) }
+
+func (b *fileBuffer) close() error {
+	return b.file.Close()
+}
@@ -0,0 +1,450 @@
+package dd
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// NET WRITER TESTS
+// ============================================================================
+
+// acceptLines accepts one connection and sends every newline-terminated line on the channel
+func acceptLines(t *testing.T, ln net.Listener) <-chan string {
+	t.Helper()
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(lines)
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	return lines
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case s, ok := <-ch:
+		if !ok {
+			t.Fatal("Listener closed before receiving a record")
+		}
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for record")
+	}
+	return ""
+}
+
+func TestNetWriterTCPNewline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	nw, err := NewNetWriter(NetWriterConfig{Network: "tcp", Address: ln.Addr().String()})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	if nw.Connected() {
+		t.Error("NetWriter should dial lazily")
+	}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{nw}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Info("over tcp")
+	nw.Write([]byte("no newline"))
+
+	if got := receive(t, lines); !strings.Contains(got, "over tcp") {
+		t.Errorf("Expected logged record, got %q", got)
+	}
+	if got := receive(t, lines); got != "no newline" {
+		t.Errorf("Expected newline framing to be added, got %q", got)
+	}
+}
+
+func TestNetWriterUnixLengthPrefix(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "dd.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	frames := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var header [4]byte
+			if _, err := io.ReadFull(conn, header[:]); err != nil {
+				close(frames)
+				return
+			}
+			payload := make([]byte, binary.BigEndian.Uint32(header[:]))
+			if _, err := io.ReadFull(conn, payload); err != nil {
+				close(frames)
+				return
+			}
+			frames <- string(payload)
+		}
+	}()
+
+	nw, err := NewNetWriter(NetWriterConfig{Network: "unix", Address: sock, Framing: FramingLengthPrefix})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	nw.Write([]byte("first\nrecord"))
+	nw.Write([]byte("second"))
+
+	if got := receive(t, frames); got != "first\nrecord" {
+		t.Errorf("Expected length-prefixed payload, got %q", got)
+	}
+	if got := receive(t, frames); got != "second" {
+		t.Errorf("Expected second payload, got %q", got)
+	}
+}
+
+func TestNetWriterUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	defer pc.Close()
+
+	nw, err := NewNetWriter(NetWriterConfig{Network: "udp", Address: pc.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	if _, err := nw.Write([]byte("datagram\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if got := string(buf[:n]); got != "datagram\n" {
+		t.Errorf("Expected datagram payload, got %q", got)
+	}
+}
+
+func TestNetWriterReconnectAndBuffer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // endpoint down
+
+	nw, err := NewNetWriter(NetWriterConfig{
+		Network:    "tcp",
+		Address:    addr,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	for _, msg := range []string{"one\n", "two\n", "three\n"} {
+		if _, err := nw.Write([]byte(msg)); err != nil {
+			t.Fatalf("Write during outage should be buffered, got: %v", err)
+		}
+	}
+	if nw.Buffered() != 3 {
+		t.Fatalf("Expected 3 buffered records, got %d", nw.Buffered())
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Could not rebind %s: %v", addr, err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	time.Sleep(30 * time.Millisecond) // let backoff expire
+	nw.Write([]byte("four\n"))
+
+	for _, want := range []string{"one", "two", "three", "four"} {
+		if got := receive(t, lines); got != want {
+			t.Errorf("Expected %q in order, got %q", want, got)
+		}
+	}
+	if nw.Buffered() != 0 {
+		t.Errorf("Buffer should be empty after reconnect, got %d", nw.Buffered())
+	}
+}
+
+func TestNetWriterBufferFull(t *testing.T) {
+	nw, err := NewNetWriter(NetWriterConfig{
+		Network:    "tcp",
+		Address:    "127.0.0.1:1",
+		BufferSize: 10,
+		MinBackoff: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	if _, err := nw.Write([]byte("12345678\n")); err != nil {
+		t.Fatalf("First write should be buffered: %v", err)
+	}
+	if _, err := nw.Write([]byte("overflow\n")); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Expected ErrBufferFull, got %v", err)
+	}
+	if nw.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped record, got %d", nw.Dropped())
+	}
+}
+
+func TestNetWriterDiskBufferSurvivesRestart(t *testing.T) {
+	bufPath := filepath.Join(t.TempDir(), "outage.buf")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	config := NetWriterConfig{Network: "tcp", Address: addr, BufferPath: bufPath, MinBackoff: time.Hour}
+	nw, err := NewNetWriter(config)
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	nw.Write([]byte("persisted\n"))
+	nw.Close()
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Could not rebind %s: %v", addr, err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	config.MinBackoff = 0
+	nw, err = NewNetWriter(config)
+	if err != nil {
+		t.Fatalf("NewNetWriter (restart) failed: %v", err)
+	}
+	defer nw.Close()
+
+	if nw.Buffered() != 1 {
+		t.Fatalf("Expected 1 record restored from disk, got %d", nw.Buffered())
+	}
+	if err := nw.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := receive(t, lines); got != "persisted" {
+		t.Errorf("Expected persisted record, got %q", got)
+	}
+}
+
+func TestFileBufferRecovery(t *testing.T) {
+	bufPath := filepath.Join(t.TempDir(), "outage.buf")
+	readFrames := func() []string {
+		t.Helper()
+		fb, err := newFileBuffer(bufPath, 1<<20)
+		if err != nil {
+			t.Fatalf("newFileBuffer failed: %v", err)
+		}
+		defer fb.close()
+		var got []string
+		if err := fb.drain(func(frame []byte) error {
+			got = append(got, string(frame))
+			return nil
+		}); err != nil {
+			t.Fatalf("drain failed: %v", err)
+		}
+		return got
+	}
+
+	fb, err := newFileBuffer(bufPath, 1<<20)
+	if err != nil {
+		t.Fatalf("newFileBuffer failed: %v", err)
+	}
+	fb.push([]byte("a"))
+	fb.push([]byte("b"))
+	fb.close()
+
+	// Simulate a crash in the middle of a frame
+	f, err := os.OpenFile(bufPath, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	f.Write(binary.BigEndian.AppendUint32(nil, 100))
+	f.Write([]byte("torn"))
+	f.Close()
+
+	fb, err = newFileBuffer(bufPath, 1<<20)
+	if err != nil {
+		t.Fatalf("newFileBuffer after crash failed: %v", err)
+	}
+	if fb.len() != 2 {
+		t.Errorf("Expected 2 records after recovery, got %d", fb.len())
+	}
+	if err := fb.push([]byte("c")); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+
+	// Send one record, then fail: the rest must stay in the file
+	var sent []string
+	errDown := errors.New("down")
+	err = fb.drain(func(frame []byte) error {
+		if len(sent) == 1 {
+			return errDown
+		}
+		sent = append(sent, string(frame))
+		return nil
+	})
+	if !errors.Is(err, errDown) || len(sent) != 1 || sent[0] != "a" {
+		t.Fatalf("drain = %v, sent %v", err, sent)
+	}
+	if err := fb.push([]byte("d")); err != nil {
+		t.Fatalf("push after drain failed: %v", err)
+	}
+	if fb.len() != 3 {
+		t.Errorf("Expected 3 buffered records, got %d", fb.len())
+	}
+	fb.close()
+
+	if got := strings.Join(readFrames(), ","); got != "b,c,d" {
+		t.Errorf("Expected records b,c,d on disk, got %q", got)
+	}
+	if _, err := os.Stat(bufPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Temporary buffer file should not remain, got %v", err)
+	}
+}
+
+func TestNetWriterNoBufferReadsDuringBackoff(t *testing.T) {
+	nw, err := NewNetWriter(NetWriterConfig{
+		Network:    "tcp",
+		Address:    "127.0.0.1:1",
+		BufferPath: filepath.Join(t.TempDir(), "outage.buf"),
+		MinBackoff: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	for range 50 {
+		if _, err := nw.Write([]byte("queued\n")); err != nil {
+			t.Fatalf("Write during outage should be buffered, got: %v", err)
+		}
+	}
+	if err := nw.Sync(); !errors.Is(err, errBackoff) {
+		t.Errorf("Expected Sync to report the backoff, got %v", err)
+	}
+
+	fb := nw.buffer.(*fileBuffer)
+	if fb.reads != 0 {
+		t.Errorf("Expected no buffer reads while backing off, got %d", fb.reads)
+	}
+	if nw.Buffered() != 50 {
+		t.Errorf("Expected 50 buffered records, got %d", nw.Buffered())
+	}
+}
+
+func TestNetWriterShutdownDeadline(t *testing.T) {
+	nw, err := NewNetWriter(NetWriterConfig{Network: "tcp", Address: "127.0.0.1:1", MinBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	nw.Write([]byte("stuck\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := nw.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if _, err := nw.Write([]byte("late\n")); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed after shutdown, got %v", err)
+	}
+}
+
+func TestNetWriterTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	if err != nil {
+		t.Fatalf("tls.Listen failed: %v", err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	nw, err := NewNetWriter(NetWriterConfig{
+		Network:   "tcp",
+		Address:   ln.Addr().String(),
+		TLSConfig: &tls.Config{RootCAs: pool, ServerName: "example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewNetWriter failed: %v", err)
+	}
+	defer nw.Close()
+
+	if _, err := nw.Write([]byte("encrypted\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := receive(t, lines); got != "encrypted" {
+		t.Errorf("Expected record over TLS, got %q", got)
+	}
+}
+
+func TestNetWriterInvalidConfig(t *testing.T) {
+	tests := []NetWriterConfig{
+		{Network: "carrier-pigeon", Address: "x"},
+		{Network: "tcp"},
+		{Network: "udp", Address: "127.0.0.1:1", TLSConfig: &tls.Config{}},
+		{Network: "tcp", Address: "127.0.0.1:1", Framing: Framing(9)},
+	}
+	for _, config := range tests {
+		if _, err := NewNetWriter(config); !errors.Is(err, ErrInvalidNetwork) {
+			t.Errorf("NewNetWriter(%+v) expected ErrInvalidNetwork, got %v", config, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package dd
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// RecoverAndLog recovers a panic, logs it at ERROR with the panic value and
+// stack as fields, and lets the goroutine return normally. It must be
+// deferred directly:
+//
+//	defer logger.RecoverAndLog()
+func (l *Logger) RecoverAndLog() {
+	if r := recover(); r != nil {
+		l.logRecovered(r, debug.Stack())
+	}
+}
+
+// RecoverAndRepanic is like RecoverAndLog but continues the panic once the
+// record has been flushed. It must be deferred directly:
+//
+//	defer logger.RecoverAndRepanic()
+func (l *Logger) RecoverAndRepanic() {
+	if r := recover(); r != nil {
+		l.logRecovered(r, debug.Stack())
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine and logs any panic it raises instead of
+// crashing the process.
+func (l *Logger) Go(fn func()) {
+	go func() {
+		defer l.RecoverAndLog()
+		fn()
+	}()
+}
+
+// logRecovered writes the recovered value and stack and syncs the writers so
+// the record survives a subsequent re-panic.
+func (l *Logger) logRecovered(r any, stack []byte) {
+	fields := []Field{Any("panic", r), String("stack", string(stack))}
+	if err, ok := r.(error); ok {
+		fields = append(fields, Err(err))
+	}
+	l.LogWith(LevelError, fmt.Sprintf("recovered from panic: %v", r), fields...)
+	_ = l.Sync()
+}
+
+// RecoverAndLog recovers a panic and logs it using the default logger. It
+// must be deferred directly.
+func RecoverAndLog() {
+	if r := recover(); r != nil {
+		Default().logRecovered(r, debug.Stack())
+	}
+}
+
+// RecoverAndRepanic recovers a panic, logs it using the default logger and
+// continues the panic. It must be deferred directly.
+func RecoverAndRepanic() {
+	if r := recover(); r != nil {
+		Default().logRecovered(r, debug.Stack())
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine, logging any panic with the default logger.
+func Go(fn func()) { Default().Go(fn) }
@@ -0,0 +1,124 @@
+package dd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// PANIC LEVEL AND RECOVER TESTS
+// ============================================================================
+
+func newRecoverTestLogger(t *testing.T, w io.Writer) *Logger {
+	t.Helper()
+	config := DefaultConfig()
+	config.Writers = []io.Writer{w}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return logger
+}
+
+func TestPanicLogsThenPanics(t *testing.T) {
+	inner := &syncCounter{}
+	bw, err := NewBufferedWriter(inner, 64*1024)
+	if err != nil {
+		t.Fatalf("Failed to create buffered writer: %v", err)
+	}
+	logger := newRecoverTestLogger(t, bw)
+
+	var flushedAtPanic string
+	func() {
+		defer func() {
+			flushedAtPanic = inner.String()
+			if r := recover(); r != "boom 42" {
+				t.Errorf("Expected panic value %q, got %v", "boom 42", r)
+			}
+		}()
+		logger.Panicf("boom %d", 42)
+	}()
+
+	if !strings.Contains(flushedAtPanic, "[PANIC]") || !strings.Contains(flushedAtPanic, "boom 42") {
+		t.Errorf("Panic record should be flushed before panicking, got: %q", flushedAtPanic)
+	}
+}
+
+func TestPanicBelowLevelStillPanics(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRecoverTestLogger(t, &buf)
+	_ = logger.SetLevel(LevelFatal)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected PanicWith to panic even when the record is filtered")
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Filtered panic record should not be written, got: %q", buf.String())
+		}
+	}()
+	logger.PanicWith("filtered", String("k", "v"))
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRecoverTestLogger(t, &buf)
+
+	func() {
+		defer logger.RecoverAndLog()
+		panic(errors.New("broken"))
+	}()
+
+	out := buf.String()
+	for _, want := range []string{"[ERROR]", "recovered from panic: broken", "panic=", "stack=", "error=broken", "recover_test.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got: %q", want, out)
+		}
+	}
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRecoverTestLogger(t, &buf)
+
+	defer func() {
+		if r := recover(); r != "again" {
+			t.Errorf("Expected re-panic with original value, got %v", r)
+		}
+		if !strings.Contains(buf.String(), "recovered from panic: again") {
+			t.Errorf("Expected recovered panic to be logged, got: %q", buf.String())
+		}
+	}()
+
+	func() {
+		defer logger.RecoverAndRepanic()
+		panic("again")
+	}()
+}
+
+func TestLoggerGo(t *testing.T) {
+	logged := make(chan string, 1)
+	logger := newRecoverTestLogger(t, writerFunc(func(p []byte) (int, error) {
+		logged <- string(p)
+		return len(p), nil
+	}))
+
+	logger.Go(func() { panic("in goroutine") })
+
+	select {
+	case out := <-logged:
+		if !strings.Contains(out, "recovered from panic: in goroutine") {
+			t.Errorf("Expected goroutine panic to be logged, got: %q", out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the goroutine panic to be logged")
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,471 @@
+package dd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// redactedText replaces a match under MaskFull.
+const redactedText = "[REDACTED]"
+
+// redactedTruncated replaces nested values beyond MaxRedactNodes.
+const redactedTruncated = "[TRUNCATED]"
+
+// MaskStrategy selects how a RedactionRule replaces a match.
+type MaskStrategy int8
+
+const (
+	// MaskFull replaces the match with [REDACTED]
+	MaskFull MaskStrategy = iota
+	// MaskKeepFirst keeps the first N letters and digits of the match
+	MaskKeepFirst
+	// MaskKeepLast keeps the last N letters and digits of the match
+	MaskKeepLast
+	// MaskFixed replaces the match with N mask characters, hiding its length
+	MaskFixed
+	// MaskTemplate replaces the match with a template that may reference
+	// capture groups as $1 or ${name}
+	MaskTemplate
+	// MaskHash replaces the match with a truncated HMAC-SHA256 token under
+	// the filter's hash key, so equal values map to equal tokens
+	MaskHash
+	// MaskEncrypt replaces the match with an AES-GCM encrypted token under
+	// the filter's encryption key, recoverable with DecryptLogLine
+	MaskEncrypt
+)
+
+func (s MaskStrategy) String() string {
+	switch s {
+	case MaskFull:
+		return "full"
+	case MaskKeepFirst:
+		return "keep_first"
+	case MaskKeepLast:
+		return "keep_last"
+	case MaskFixed:
+		return "fixed"
+	case MaskTemplate:
+		return "template"
+	case MaskHash:
+		return "hash"
+	case MaskEncrypt:
+		return "encrypt"
+	default:
+		return "unknown"
+	}
+}
+
+// Mask describes the replacement applied to a match. The zero value
+// redacts the whole match.
+type Mask struct {
+	Strategy MaskStrategy
+	// N is the number of characters kept (MaskKeepFirst, MaskKeepLast),
+	// the length of the mask (MaskFixed) or the number of hex digits of the
+	// token (MaskHash, default 12)
+	N int
+	// Char is the mask character; defaults to '*'
+	Char rune
+	// Template is the replacement for MaskTemplate, in regexp.Expand syntax
+	Template string
+}
+
+// FullMask replaces the whole match with [REDACTED].
+func FullMask() Mask { return Mask{Strategy: MaskFull} }
+
+// KeepFirstMask keeps the first n letters and digits and masks the rest.
+func KeepFirstMask(n int) Mask { return Mask{Strategy: MaskKeepFirst, N: n} }
+
+// KeepLastMask keeps the last n letters and digits and masks the rest, for
+// example the last four digits of a card number.
+func KeepLastMask(n int) Mask { return Mask{Strategy: MaskKeepLast, N: n} }
+
+// FixedMask replaces the match with n mask characters.
+func FixedMask(n int) Mask { return Mask{Strategy: MaskFixed, N: n} }
+
+// TemplateMask replaces the match with template, which may reference
+// capture groups, e.g. "***@${domain}" to keep the domain of an email.
+func TemplateMask(template string) Mask { return Mask{Strategy: MaskTemplate, Template: template} }
+
+// HashMask pseudonymizes the match as "<rule>:h_<hex>" using the key set
+// with SensitiveDataFilter.SetHashKey; n is the number of hex digits kept
+// (0 uses the default).
+func HashMask(n int) Mask { return Mask{Strategy: MaskHash, N: n} }
+
+// EncryptMask replaces the match with "enc:v1:<kid>:<base64>", encrypted
+// under the key set with SensitiveDataFilter.SetEncryptionKey, so that
+// holders of the key can restore it with DecryptLogLine.
+func EncryptMask() Mask { return Mask{Strategy: MaskEncrypt} }
+
+func (m Mask) validate() error {
+	switch m.Strategy {
+	case MaskFull, MaskTemplate, MaskEncrypt:
+		return nil
+	case MaskKeepFirst, MaskKeepLast, MaskFixed, MaskHash:
+		if m.N < 0 {
+			return fmt.Errorf("%w: %s mask length must not be negative", ErrInvalidPattern, m.Strategy)
+		}
+		if m.Strategy == MaskHash && m.N > sha256.Size*2 {
+			return fmt.Errorf("%w: hash tokens are at most %d hex digits", ErrInvalidPattern, sha256.Size*2)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown mask strategy %d", ErrInvalidPattern, m.Strategy)
+	}
+}
+
+func (m Mask) maskChar() rune {
+	if m.Char == 0 {
+		return '*'
+	}
+	return m.Char
+}
+
+// maskValue applies a KeepFirst, KeepLast or Fixed mask to one match.
+// Separators such as spaces and dashes are kept so that the shape of the
+// value stays recognisable.
+func (m Mask) maskValue(match string) string {
+	if m.Strategy == MaskFixed {
+		return strings.Repeat(string(m.maskChar()), m.N)
+	}
+
+	runes := []rune(match)
+	total := 0
+	for _, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			total++
+		}
+	}
+
+	seen := 0
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		seen++
+		keep := (m.Strategy == MaskKeepFirst && seen <= m.N) ||
+			(m.Strategy == MaskKeepLast && seen > total-m.N)
+		if !keep {
+			runes[i] = m.maskChar()
+		}
+	}
+	return string(runes)
+}
+
+// RedactionRule is a named pattern with its own masking strategy.
+type RedactionRule struct {
+	Name    string
+	Pattern string
+	Mask    Mask
+	// Validate, if set, is called with every match; matches it rejects are
+	// left as they are. See ValidLuhn, ValidIBAN and ValidSSN.
+	Validate func(match string) bool
+	// DryRun counts and reports matches without masking them
+	DryRun bool
+}
+
+// RuleStats counts the matches of one rule.
+type RuleStats struct {
+	Name string
+	// Hits counts matches that were masked
+	Hits uint64
+	// DryRunHits counts matches that were reported but left unmasked
+	// because the rule or filter was in dry-run mode
+	DryRunHits uint64
+	// Rejected counts candidate matches that failed the rule's validator
+	Rejected uint64
+}
+
+// ruleCounters is shared by the copies of a compiled rule.
+type ruleCounters struct {
+	hits       atomic.Uint64
+	dryRunHits atomic.Uint64
+	rejected   atomic.Uint64
+}
+
+// redactionRule is a compiled RedactionRule.
+type redactionRule struct {
+	name      string
+	pattern   *regexp.Regexp
+	mask      Mask
+	validate  func(string) bool
+	dryRun    bool
+	counters  *ruleCounters
+	hashKey   *hashKey       // bound by Filter for MaskHash
+	encKey    *encryptionKey // bound by Filter for MaskEncrypt
+	prefilter []ruleLiteral  // literals one of which every match contains
+	// find, if set, replaces the regexp search with a hand-written scanner
+	// returning match indexes in FindAllStringSubmatchIndex form
+	find func(input string) [][]int
+}
+
+func compileRule(rule RedactionRule) (redactionRule, error) {
+	if err := rule.Mask.validate(); err != nil {
+		return redactionRule{}, err
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return redactionRule{}, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+	return redactionRule{
+		name:      rule.Name,
+		pattern:   re,
+		mask:      rule.Mask,
+		validate:  rule.Validate,
+		dryRun:    rule.DryRun,
+		counters:  &ruleCounters{},
+		prefilter: prefilterLiterals(rule.Pattern),
+	}, nil
+}
+
+// appendMask appends the replacement for the match at loc to out.
+func (r *redactionRule) appendMask(out []byte, input string, loc []int) []byte {
+	switch r.mask.Strategy {
+	case MaskFull:
+		return append(out, redactedText...)
+	case MaskTemplate:
+		return r.pattern.ExpandString(out, r.mask.Template, input, loc)
+	case MaskHash:
+		return append(out, r.hashKey.token(r.name, input[loc[0]:loc[1]], r.mask.N)...)
+	case MaskEncrypt:
+		return append(out, r.encKey.token(input[loc[0]:loc[1]])...)
+	default:
+		return append(out, r.mask.maskValue(input[loc[0]:loc[1]])...)
+	}
+}
+
+// findAll returns the indexes of every candidate match in input.
+func (r *redactionRule) findAll(input string) [][]int {
+	if r.find != nil {
+		return r.find(input)
+	}
+	return r.pattern.FindAllStringSubmatchIndex(input, -1)
+}
+
+func (r redactionRule) stats() RuleStats {
+	return RuleStats{
+		Name:       r.name,
+		Hits:       r.counters.hits.Load(),
+		DryRunHits: r.counters.dryRunHits.Load(),
+		Rejected:   r.counters.rejected.Load(),
+	}
+}
+
+// maskWhole applies mask to an entire value, as done for the values of
+// sensitive field keys. Template masks have no match to expand and fall
+// back to full redaction.
+func maskWhole(mask Mask, hk *hashKey, ek *encryptionKey, label, value string) string {
+	switch mask.Strategy {
+	case MaskKeepFirst, MaskKeepLast, MaskFixed:
+		return mask.maskValue(value)
+	case MaskHash:
+		return hk.token(label, value, mask.N)
+	case MaskEncrypt:
+		return ek.token(value)
+	default:
+		return redactedText
+	}
+}
+
+// nestedRedaction carries the filter state through a TypeConverter walk of
+// a structured field value.
+type nestedRedaction struct {
+	filter  *SensitiveDataFilter
+	key     string // field key of the walked value
+	keyMask Mask
+	hashKey *hashKey
+	encKey  *encryptionKey
+	nodes   int
+	changed bool
+}
+
+// visit counts one value and reports whether the size limit still allows
+// it to be walked.
+func (r *nestedRedaction) visit() bool {
+	r.nodes++
+	if r.nodes > MaxRedactNodes {
+		r.changed = true
+		return false
+	}
+	return true
+}
+
+// filterString applies the filter's rules to a nested string.
+func (r *nestedRedaction) filterString(val reflect.Value) any {
+	s := val.String()
+	if filtered := r.filter.filterString(s, r.key); filtered != s {
+		r.changed = true
+		return filtered
+	}
+	return val.Interface()
+}
+
+// maskValue masks the value of a sensitive key or of a field tagged
+// dd:"redact". Strings get the key mask; anything else is replaced whole.
+// It is safe on a nil receiver, which redacts with the default mask.
+func (r *nestedRedaction) maskValue(label string, val reflect.Value) any {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return nil
+	}
+	if r == nil {
+		return redactedText
+	}
+
+	r.changed = true
+	audit := r.filter.newAudit(label)
+	if val.Kind() == reflect.String {
+		s := val.String()
+		audit.report(SensitiveKeyRuleName, s, 0, len(s), audit.dryRun)
+		return maskWhole(r.keyMask, r.hashKey, r.encKey, label, s)
+	}
+	audit.report(SensitiveKeyRuleName, "", 0, 0, audit.dryRun)
+	return redactedText
+}
+
+// isNestedValue reports whether v is a map, slice, array or struct (or a
+// pointer to one) that filterNested should walk. Byte slices are left alone.
+func isNestedValue(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Array, reflect.Struct:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+// defaultHashDigits is the token length used when Mask.N is 0.
+const defaultHashDigits = 12
+
+// hashKey is the secret used by MaskHash. The key ID is embedded in tokens
+// so that tokens produced before and after a key rotation can be told apart.
+type hashKey struct {
+	id  string
+	key []byte
+}
+
+// token returns "<label>:h_<id>_<hex>", leaving out the label and id when
+// they are empty. Without a key the value is fully redacted rather than
+// hashed with a guessable key.
+func (k *hashKey) token(label, value string, digits int) string {
+	if k == nil {
+		return redactedText
+	}
+	if digits <= 0 {
+		digits = defaultHashDigits
+	}
+
+	mac := hmac.New(sha256.New, k.key)
+	mac.Write([]byte(value))
+	sum := hex.EncodeToString(mac.Sum(nil))[:digits]
+
+	var sb strings.Builder
+	if label != "" {
+		sb.WriteString(label)
+		sb.WriteByte(':')
+	}
+	sb.WriteString("h_")
+	if k.id != "" {
+		sb.WriteString(k.id)
+		sb.WriteByte('_')
+	}
+	sb.WriteString(sum)
+	return sb.String()
+}
+
+// ValidLuhn reports whether the digits in s, ignoring spaces and dashes,
+// form a 13 to 19 digit number with a valid Luhn check digit, as payment
+// card numbers do.
+func ValidLuhn(s string) bool {
+	digits := make([]int, 0, 19)
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		case r == ' ' || r == '-':
+		default:
+			return false
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// ValidIBAN reports whether s, ignoring spaces, is an IBAN with a valid
+// ISO 13616 mod-97 checksum.
+func ValidIBAN(s string) bool {
+	iban := strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	for i := 0; i < 2; i++ {
+		if iban[i] < 'A' || iban[i] > 'Z' || iban[i+2] < '0' || iban[i+2] > '9' {
+			return false
+		}
+	}
+
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// ValidSSN reports whether s is a US social security number that could
+// have been issued: the area is not 000, 666 or 900-999, and neither the
+// group nor the serial number is all zeros.
+func ValidSSN(s string) bool {
+	digits := strings.ReplaceAll(s, "-", "")
+	if len(digits) != 9 {
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+	}
+
+	area, group, serial := digits[:3], digits[3:5], digits[5:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
@@ -0,0 +1,121 @@
+package dd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SensitiveKeyRuleName is the rule name reported when a value is masked
+// because its field key is sensitive.
+const SensitiveKeyRuleName = "sensitive_key"
+
+// RedactionEvent describes one match found by a SensitiveDataFilter. It
+// never contains the matched text.
+type RedactionEvent struct {
+	// Rule is the name of the matching rule, or SensitiveKeyRuleName
+	Rule string `json:"rule"`
+	// Key is the field key of the filtered value; empty for messages
+	Key string `json:"key,omitempty"`
+	// Start and End are the byte offsets of the match in the filtered value
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Hash is a keyed hash of the match, set only when the filter has a
+	// hash key (see SetHashKey), so that repeated values can be correlated
+	Hash string `json:"hash,omitempty"`
+	// DryRun is true when the match was reported but left in the output
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// RedactionReporter receives redaction events. It is called synchronously
+// from the logging goroutine and must not log through the filtered logger.
+type RedactionReporter func(RedactionEvent)
+
+// RedactionReportWriter returns a reporter that writes each event to w as
+// a JSON line, serializing concurrent calls.
+func RedactionReportWriter(w io.Writer) RedactionReporter {
+	var mu sync.Mutex
+	return func(event RedactionEvent) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(data, '\n'))
+	}
+}
+
+// SetReporter sets a reporter called for every match, or removes it when
+// reporter is nil. Together with RuleStats it provides an audit trail of
+// what the filter hides.
+func (f *SensitiveDataFilter) SetReporter(reporter RedactionReporter) {
+	if reporter == nil {
+		f.reporter.Store(nil)
+		return
+	}
+	f.reporter.Store(&reporter)
+}
+
+// SetDryRun switches the whole filter to dry-run mode, in which matches
+// are counted and reported but the output is left unchanged.
+func (f *SensitiveDataFilter) SetDryRun(dryRun bool) {
+	f.dryRun.Store(dryRun)
+}
+
+// IsDryRun reports whether the filter is in dry-run mode.
+func (f *SensitiveDataFilter) IsDryRun() bool {
+	return f != nil && f.dryRun.Load()
+}
+
+// SetRuleDryRun puts every rule with the given name in or out of dry-run
+// mode, so that a new pattern can be tuned while the others are enforced.
+func (f *SensitiveDataFilter) SetRuleDryRun(name string, dryRun bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	found := false
+	rules := make([]redactionRule, len(f.rules))
+	for i, rule := range f.rules {
+		if rule.name == name {
+			rule.dryRun = dryRun
+			found = true
+		}
+		rules[i] = rule
+	}
+	if !found {
+		return fmt.Errorf("%w: no rule named %q", ErrInvalidPattern, name)
+	}
+	f.rules = rules
+	return nil
+}
+
+// redactionAudit carries the reporting state for one filtered value.
+type redactionAudit struct {
+	key      string
+	dryRun   bool
+	reporter RedactionReporter
+	hashKey  *hashKey
+}
+
+// newAudit returns the audit state for a value stored under key.
+func (f *SensitiveDataFilter) newAudit(key string) redactionAudit {
+	audit := redactionAudit{key: key, dryRun: f.dryRun.Load(), hashKey: f.hashKey.Load()}
+	if reporter := f.reporter.Load(); reporter != nil {
+		audit.reporter = *reporter
+	}
+	return audit
+}
+
+// report sends an event for match, found at [start, end) by rule.
+func (a redactionAudit) report(rule, match string, start, end int, dryRun bool) {
+	if a.reporter == nil {
+		return
+	}
+	event := RedactionEvent{Rule: rule, Key: a.key, Start: start, End: end, DryRun: dryRun}
+	if a.hashKey != nil && match != "" {
+		event.Hash = a.hashKey.token("", match, 0)
+	}
+	a.reporter(event)
+}
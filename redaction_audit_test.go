@@ -0,0 +1,195 @@
+package dd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ============================================================================
+// REDACTION AUDIT TESTS
+// ============================================================================
+
+// eventRecorder collects redaction events.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []RedactionEvent
+}
+
+func (r *eventRecorder) report(event RedactionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestRedactionDryRun(t *testing.T) {
+	filter := NewSensitiveDataFilter()
+	var rec eventRecorder
+	filter.SetReporter(rec.report)
+	filter.SetDryRun(true)
+
+	input := "mail bob@example.com card 4111 1111 1111 1111"
+	if got := filter.Filter(input); got != input {
+		t.Errorf("Dry run should not change the output, got %q", got)
+	}
+	if !filter.IsDryRun() {
+		t.Error("IsDryRun should report dry-run mode")
+	}
+
+	want := map[string][2]int{"email": {5, 20}, "credit_card": {26, 45}}
+	if len(rec.events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), rec.events)
+	}
+	for _, event := range rec.events {
+		span, ok := want[event.Rule]
+		if !ok || event.Start != span[0] || event.End != span[1] || !event.DryRun {
+			t.Errorf("Unexpected event %+v", event)
+		}
+		if event.Hash != "" {
+			t.Errorf("Events should not carry a hash without a hash key: %+v", event)
+		}
+	}
+
+	counts := make(map[string]RuleStats)
+	for _, rs := range filter.RuleStats() {
+		counts[rs.Name] = rs
+	}
+	if counts["email"].DryRunHits != 1 || counts["credit_card"].DryRunHits != 1 {
+		t.Errorf("Dry-run matches should be counted: %v", counts)
+	}
+	if counts["email"].Hits != 0 || counts["credit_card"].Hits != 0 {
+		t.Errorf("Dry-run matches should not count as masked: %v", counts)
+	}
+
+	filter.SetDryRun(false)
+	if got := filter.Filter(input); got != "mail [REDACTED] card [REDACTED]" {
+		t.Errorf("Unexpected enforced output %q", got)
+	}
+	for _, rs := range filter.RuleStats() {
+		if rs.Name == "email" && (rs.Hits != 1 || rs.DryRunHits != 1) {
+			t.Errorf("Expected one masked and one dry-run email match, got %+v", rs)
+		}
+	}
+	if last := rec.events[len(rec.events)-1]; last.DryRun {
+		t.Errorf("Enforced matches should not be marked as dry run: %+v", last)
+	}
+}
+
+func TestRuleDryRun(t *testing.T) {
+	filter := NewSensitiveDataFilter()
+	var rec eventRecorder
+	filter.SetReporter(rec.report)
+
+	if err := filter.AddRule(RedactionRule{Name: "order_id", Pattern: `\bORD-[0-9]{6}\b`, DryRun: true}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	if err := filter.SetRuleDryRun("email", true); err != nil {
+		t.Fatalf("SetRuleDryRun failed: %v", err)
+	}
+	if err := filter.SetRuleDryRun("missing", true); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern for an unknown rule, got %v", err)
+	}
+
+	got := filter.Filter("ORD-123456 for bob@example.com paid with 4111-1111-1111-1111")
+	if got != "ORD-123456 for bob@example.com paid with [REDACTED]" {
+		t.Errorf("Only enforced rules should mask, got %q", got)
+	}
+
+	dryRun := make(map[string]bool)
+	for _, event := range rec.events {
+		dryRun[event.Rule] = event.DryRun
+	}
+	if !dryRun["order_id"] || !dryRun["email"] || dryRun["credit_card"] {
+		t.Errorf("Unexpected dry-run flags: %v", dryRun)
+	}
+}
+
+func TestRedactionReportWriter(t *testing.T) {
+	var report bytes.Buffer
+	filter := NewBasicSensitiveDataFilter()
+	_ = filter.SetHashKey("k1", []byte("audit-key"))
+	filter.SetReporter(RedactionReportWriter(&report))
+
+	var buf bytes.Buffer
+	config := JSONConfig()
+	config.Writers = []io.Writer{&buf}
+	config.SecurityConfig = &SecurityConfig{SensitiveFilter: filter}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.InfoWith("login", String("password", "hunter2"), String("note", "api_key=abc123def"))
+
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 report lines, got %q", report.String())
+	}
+	if strings.Contains(report.String(), "hunter2") || strings.Contains(report.String(), "abc123def") {
+		t.Errorf("Reports must not contain secrets: %s", report.String())
+	}
+
+	var first, second RedactionEvent
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Invalid report line %q: %v", lines[0], err)
+	}
+	_ = json.Unmarshal([]byte(lines[1]), &second)
+	if first.Rule != SensitiveKeyRuleName || first.Key != "password" || first.End != len("hunter2") {
+		t.Errorf("Unexpected key event %+v", first)
+	}
+	if second.Rule != "api_key" || second.Key != "note" || !strings.HasPrefix(second.Hash, "h_k1_") {
+		t.Errorf("Unexpected rule event %+v", second)
+	}
+}
+
+// entryCollector is an EntryWriter that keeps the entries it receives.
+type entryCollector struct {
+	bytes.Buffer
+	entries []*Entry
+}
+
+func (c *entryCollector) WriteEntry(entry *Entry) error {
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+func TestRedactionCountedOncePerRecord(t *testing.T) {
+	filter := NewSensitiveDataFilter()
+	var rec eventRecorder
+	filter.SetReporter(rec.report)
+
+	var line bytes.Buffer
+	collector := &entryCollector{}
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&line, collector}
+	config.SecurityConfig = &SecurityConfig{SensitiveFilter: filter}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.InfoWith("mail bob@example.com", Any("cause", errors.New("password=hunter2")))
+
+	if got := logger.Stats().Redactions; got != 2 {
+		t.Errorf("Expected one redaction for the message and one for the field, got %d", got)
+	}
+	if len(rec.events) != 2 {
+		t.Errorf("Expected 2 redaction events, got %+v", rec.events)
+	}
+	if strings.Contains(line.String(), "bob@example.com") || strings.Contains(line.String(), "hunter2") {
+		t.Errorf("Formatted line should be filtered: %q", line.String())
+	}
+	if len(collector.entries) != 1 || collector.entries[0].Message != "mail [REDACTED]" {
+		t.Fatalf("Entry should carry the filtered message, got %+v", collector.entries)
+	}
+	if cause := collector.entries[0].Fields[0].Value; strings.Contains(fmt.Sprint(cause), "hunter2") {
+		t.Errorf("Error field should be filtered, got %v", cause)
+	}
+}
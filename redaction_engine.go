@@ -0,0 +1,211 @@
+package dd
+
+import (
+	"regexp/syntax"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// The redaction engine finds the matches of every rule in the original
+// input and builds the result in a single buffer. Earlier rules take
+// precedence: a match that overlaps one accepted from an earlier rule is
+// dropped, so the outcome does not depend on how the input is split.
+// Rules whose required literals do not occur in the input are skipped
+// without running their regular expression.
+
+// ruleLiteral is a substring that any match of a rule must contain.
+type ruleLiteral struct {
+	text string
+	fold bool // compare case-insensitively; text is lower-case
+}
+
+// ruleMatch is an accepted match of rules[rule], or a protected span that
+// is copied unchanged when rule is negative.
+type ruleMatch struct {
+	rule int
+	loc  []int // submatch indexes as returned by FindAllStringSubmatchIndex
+}
+
+// prefilterLiterals returns literals of which every match of pattern
+// contains at least one, or nil when no such set is known.
+func prefilterLiterals(pattern string) []ruleLiteral {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	literals, ok := requiredLiterals(re.Simplify())
+	if !ok {
+		return nil
+	}
+	return literals
+}
+
+// requiredLiterals walks the syntax tree for a set of literals, one of
+// which occurs in every match.
+func requiredLiterals(re *syntax.Regexp) ([]ruleLiteral, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		lit := ruleLiteral{text: string(re.Rune), fold: re.Flags&syntax.FoldCase != 0}
+		if lit.fold {
+			lit.text = strings.ToLower(lit.text)
+		}
+		return []ruleLiteral{lit}, true
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min < 1 {
+			return nil, false
+		}
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpConcat:
+		// Prefer the sub-expression whose shortest literal is longest.
+		var best []ruleLiteral
+		bestLen := 0
+		for _, sub := range re.Sub {
+			literals, ok := requiredLiterals(sub)
+			if !ok {
+				continue
+			}
+			shortest := len(literals[0].text)
+			for _, lit := range literals[1:] {
+				shortest = min(shortest, len(lit.text))
+			}
+			if shortest > bestLen {
+				best, bestLen = literals, shortest
+			}
+		}
+		return best, best != nil
+
+	case syntax.OpAlternate:
+		var all []ruleLiteral
+		for _, sub := range re.Sub {
+			literals, ok := requiredLiterals(sub)
+			if !ok {
+				return nil, false
+			}
+			all = append(all, literals...)
+		}
+		return all, true
+
+	default:
+		return nil, false
+	}
+}
+
+// engineInput caches derived forms of the input for the prefilter.
+type engineInput struct {
+	text    string
+	lower   string
+	lowered bool
+	ascii   bool
+}
+
+func newEngineInput(text string) engineInput {
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	return engineInput{text: text, ascii: ascii}
+}
+
+// mayMatch reports whether the rule could match the input. Case-folded
+// literals are only checked on ASCII input, where lower-casing agrees with
+// the regexp package's case folding.
+func (in *engineInput) mayMatch(literals []ruleLiteral) bool {
+	if len(literals) == 0 {
+		return true
+	}
+	for _, lit := range literals {
+		if !lit.fold {
+			if strings.Contains(in.text, lit.text) {
+				return true
+			}
+			continue
+		}
+		if !in.ascii {
+			return true
+		}
+		if !in.lowered {
+			in.lower, in.lowered = strings.ToLower(in.text), true
+		}
+		if strings.Contains(in.lower, lit.text) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAll applies rules to input in one pass and returns the result.
+// Matches of dry-run rules are counted and reported but neither masked nor
+// allowed to shadow later rules. Tokens encrypted under ek are kept as is.
+func redactAll(input string, rules []redactionRule, audit redactionAudit, ek *encryptionKey) string {
+	in := newEngineInput(input)
+	accepted := ek.protectedSpans(input)
+	protected := len(accepted)
+
+	for i := range rules {
+		r := &rules[i]
+		if !in.mayMatch(r.prefilter) {
+			continue
+		}
+		for _, loc := range r.findAll(input) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			pos, free := insertPosition(accepted, loc[0], loc[1])
+			if !free {
+				continue
+			}
+			if r.validate != nil && !r.validate(input[loc[0]:loc[1]]) {
+				r.counters.rejected.Add(1)
+				continue
+			}
+			dryRun := audit.dryRun || r.dryRun
+			audit.report(r.name, input[loc[0]:loc[1]], loc[0], loc[1], dryRun)
+			if dryRun {
+				r.counters.dryRunHits.Add(1)
+				continue
+			}
+			r.counters.hits.Add(1)
+			accepted = slices.Insert(accepted, pos, ruleMatch{rule: i, loc: loc})
+		}
+	}
+	if len(accepted) == protected {
+		return input
+	}
+
+	out := make([]byte, 0, len(input))
+	last := 0
+	for _, m := range accepted {
+		out = append(out, input[last:m.loc[0]]...)
+		if m.rule < 0 {
+			out = append(out, input[m.loc[0]:m.loc[1]]...)
+		} else {
+			out = rules[m.rule].appendMask(out, input, m.loc)
+		}
+		last = m.loc[1]
+	}
+	out = append(out, input[last:]...)
+	return string(out)
+}
+
+// insertPosition returns where a match covering [start, end) belongs in
+// the sorted accepted list, and whether it overlaps no accepted match.
+func insertPosition(accepted []ruleMatch, start, end int) (int, bool) {
+	pos := sort.Search(len(accepted), func(i int) bool { return accepted[i].loc[0] >= start })
+	if pos > 0 && accepted[pos-1].loc[1] > start {
+		return pos, false
+	}
+	if pos < len(accepted) && accepted[pos].loc[0] < end {
+		return pos, false
+	}
+	return pos, true
+}
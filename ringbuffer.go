@@ -0,0 +1,122 @@
+package dd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// RingBufferWriter keeps the most recent records in memory, overwriting the
+// oldest once full. Used as a Logger's FlightRecorder it holds records below
+// the logger level until an error makes them worth writing.
+type RingBufferWriter struct {
+	mu      sync.Mutex
+	records [][]byte
+	next    int
+	count   int
+}
+
+// NewRingBufferWriter creates a ring holding up to capacity records
+// (DefaultRingBufferSize if capacity <= 0).
+func NewRingBufferWriter(capacity int) *RingBufferWriter {
+	if capacity <= 0 {
+		capacity = DefaultRingBufferSize
+	}
+	return &RingBufferWriter{records: make([][]byte, capacity)}
+}
+
+// Write stores a copy of p as one record.
+func (rb *RingBufferWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	record := append([]byte(nil), p...)
+
+	rb.mu.Lock()
+	rb.records[rb.next] = record
+	rb.next = (rb.next + 1) % len(rb.records)
+	if rb.count < len(rb.records) {
+		rb.count++
+	}
+	rb.mu.Unlock()
+
+	return len(p), nil
+}
+
+// Len returns the number of records held.
+func (rb *RingBufferWriter) Len() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.count
+}
+
+// Cap returns the maximum number of records held.
+func (rb *RingBufferWriter) Cap() int {
+	return len(rb.records)
+}
+
+// Records returns the held records, oldest first, without removing them.
+func (rb *RingBufferWriter) Records() [][]byte {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.recordsLocked()
+}
+
+// Drain returns the held records, oldest first, and empties the ring.
+func (rb *RingBufferWriter) Drain() [][]byte {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	records := rb.recordsLocked()
+	clear(rb.records)
+	rb.next, rb.count = 0, 0
+	return records
+}
+
+func (rb *RingBufferWriter) recordsLocked() [][]byte {
+	records := make([][]byte, 0, rb.count)
+	start := (rb.next - rb.count + len(rb.records)) % len(rb.records)
+	for i := range rb.count {
+		records = append(records, rb.records[(start+i)%len(rb.records)])
+	}
+	return records
+}
+
+// DumpTo drains the ring into w, oldest record first.
+func (rb *RingBufferWriter) DumpTo(w io.Writer) error {
+	for _, record := range rb.Drain() {
+		if _, err := w.Write(record); err != nil {
+			return fmt.Errorf("ring buffer dump: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reset empties the ring.
+func (rb *RingBufferWriter) Reset() {
+	rb.Drain()
+}
+
+// DumpFlightRecorder writes the records held by the flight recorder to the
+// logger's writers and empties it. It does nothing if no FlightRecorder is
+// configured.
+func (l *Logger) DumpFlightRecorder() {
+	if l.recorder == nil || l.closed.Load() {
+		return
+	}
+	for _, record := range l.recorder.Drain() {
+		l.writeRecord(record, nil)
+	}
+}
+
+// DumpOnPanic dumps the flight recorder if the goroutine is panicking and
+// then continues the panic. It must be deferred directly:
+//
+//	defer logger.DumpOnPanic()
+func (l *Logger) DumpOnPanic() {
+	if r := recover(); r != nil {
+		l.DumpFlightRecorder()
+		_ = l.Sync()
+		panic(r)
+	}
+}
@@ -0,0 +1,148 @@
+package dd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// RING BUFFER WRITER TESTS
+// ============================================================================
+
+func TestRingBufferWriterWraps(t *testing.T) {
+	rb := NewRingBufferWriter(3)
+	for _, msg := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+		rb.Write([]byte(msg))
+	}
+
+	if rb.Len() != 3 || rb.Cap() != 3 {
+		t.Fatalf("Expected 3/3 records, got %d/%d", rb.Len(), rb.Cap())
+	}
+	if got := string(bytes.Join(rb.Records(), nil)); got != "3\n4\n5\n" {
+		t.Errorf("Expected newest records oldest first, got %q", got)
+	}
+
+	var out bytes.Buffer
+	if err := rb.DumpTo(&out); err != nil {
+		t.Fatalf("DumpTo failed: %v", err)
+	}
+	if out.String() != "3\n4\n5\n" || rb.Len() != 0 {
+		t.Errorf("DumpTo should drain in order, got %q with %d left", out.String(), rb.Len())
+	}
+
+	rb.Write([]byte("6\n"))
+	if got := string(bytes.Join(rb.Drain(), nil)); got != "6\n" {
+		t.Errorf("Expected only the new record after a drain, got %q", got)
+	}
+}
+
+func TestRingBufferWriterDefaultCapacity(t *testing.T) {
+	if rb := NewRingBufferWriter(0); rb.Cap() != DefaultRingBufferSize {
+		t.Errorf("Expected default capacity %d, got %d", DefaultRingBufferSize, rb.Cap())
+	}
+}
+
+func newFlightRecorderLogger(t *testing.T, buf *bytes.Buffer, recorder *RingBufferWriter) *Logger {
+	t.Helper()
+	config := DefaultConfig()
+	config.Writers = []io.Writer{buf}
+	config.FlightRecorder = recorder
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	return logger
+}
+
+func TestFlightRecorderDumpsOnError(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRingBufferWriter(2)
+	logger := newFlightRecorderLogger(t, &buf, recorder)
+
+	logger.Debug("step one")
+	logger.Debugf("step %s", "two")
+	logger.DebugWith("step three", Int("n", 3))
+	logger.Info("visible")
+
+	if out := buf.String(); strings.Contains(out, "step") || !strings.Contains(out, "visible") {
+		t.Fatalf("Debug records should be held back, got %q", out)
+	}
+	if recorder.Len() != 2 {
+		t.Fatalf("Expected the 2 most recent debug records, got %d", recorder.Len())
+	}
+
+	logger.Error("boom")
+
+	out := buf.String()
+	two, three, boom := strings.Index(out, "step two"), strings.Index(out, "step three n=3"), strings.Index(out, "boom")
+	if strings.Contains(out, "step one") || two < 0 || three < two || boom < three {
+		t.Errorf("Expected recorded debug records ahead of the error, got %q", out)
+	}
+	if recorder.Len() != 0 {
+		t.Errorf("Flight recorder should be empty after a dump, got %d", recorder.Len())
+	}
+}
+
+func TestFlightRecorderLevel(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRingBufferWriter(10)
+
+	config := DefaultConfig()
+	config.Level = LevelWarn
+	config.Writers = []io.Writer{&buf}
+	config.FlightRecorder = recorder
+	config.FlightRecorderLevel = LevelInfo
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Debug("too verbose")
+	logger.Info("kept")
+	if recorder.Len() != 1 {
+		t.Errorf("Only records at FlightRecorderLevel or above should be captured, got %d", recorder.Len())
+	}
+
+	config.FlightRecorderLevel = LogLevel(42)
+	if _, err := New(config); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("Expected ErrInvalidLevel for bad recorder level, got %v", err)
+	}
+}
+
+func TestFlightRecorderDumpOnDemand(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newFlightRecorderLogger(t, &buf, NewRingBufferWriter(10))
+
+	logger.Debug("context")
+	logger.DumpFlightRecorder()
+
+	if !strings.Contains(buf.String(), "context") {
+		t.Errorf("Expected on-demand dump, got %q", buf.String())
+	}
+}
+
+func TestFlightRecorderDumpOnPanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newFlightRecorderLogger(t, &buf, NewRingBufferWriter(10))
+
+	func() {
+		defer func() {
+			if r := recover(); r != "kaboom" {
+				t.Errorf("Panic should be re-raised, got %v", r)
+			}
+		}()
+		defer logger.DumpOnPanic()
+
+		logger.Debug("before panic")
+		panic("kaboom")
+	}()
+
+	if !strings.Contains(buf.String(), "before panic") {
+		t.Errorf("Expected dump on panic, got %q", buf.String())
+	}
+}
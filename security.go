@@ -3,7 +3,7 @@ package dd
 import (
 	"context"
 	"fmt"
-	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -11,51 +11,37 @@ import (
 )
 
 type SensitiveDataFilter struct {
-	patterns       []*regexp.Regexp
+	rules          []redactionRule
 	mu             sync.RWMutex
 	maxInputLength int
 	timeout        time.Duration
 	enabled        atomic.Bool
+	keyMask        Mask // applied to values of sensitive field keys
+	hashKey        atomic.Pointer[hashKey]
+	keys           atomic.Pointer[keySet] // nil uses defaultKeySet
+	reporter       atomic.Pointer[RedactionReporter]
+	encKey         atomic.Pointer[encryptionKey]
+	dryRun         atomic.Bool
 }
 
 func NewSensitiveDataFilter() *SensitiveDataFilter {
 	filter := &SensitiveDataFilter{
-		patterns:       make([]*regexp.Regexp, 0, 12),
+		rules:          make([]redactionRule, 0, 12),
 		maxInputLength: MaxInputLength,
 		timeout:        DefaultFilterTimeout,
 	}
 	filter.enabled.Store(true)
 
 	// Optimized security patterns - ReDoS resistant with strict boundaries
-	patterns := []string{
-		// Credit card numbers (13-19 digits with optional separators)
-		`\b[0-9]{4}[- ]?[0-9]{4}[- ]?[0-9]{4}[- ]?[0-9]{3,7}\b`,
-		// SSN (strict format)
-		`\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`,
-		// Password/secret fields (length limited)
-		`(?i)(?:password|passwd|pwd|secret)[\s:=]+[^\s]{1,32}\b`,
-		// API keys and tokens (length limited)
-		`(?i)(?:token|api[_-]?key|bearer)[\s:=]+[^\s]{1,128}\b`,
-		// JWT tokens (strict three-part format)
-		`\beyJ[A-Za-z0-9_-]{10,100}\.eyJ[A-Za-z0-9_-]{10,100}\.[A-Za-z0-9_-]{10,100}\b`,
-		// Private keys (bounded content)
-		`-----BEGIN[^-]{1,20}PRIVATE\s+KEY-----[A-Za-z0-9+/=\s]{1,4000}-----END[^-]{1,20}PRIVATE\s+KEY-----`,
-		// AWS Access Keys
-		`\bAKIA[0-9A-Z]{16}\b`,
-		// Google API Keys
-		`\bAIza[A-Za-z0-9_-]{35}\b`,
-		// OpenAI API Keys
-		`\bsk-[A-Za-z0-9]{20,48}\b`,
-		// Email addresses (simplified but secure)
-		`\b[A-Za-z0-9._%+-]{1,64}@[A-Za-z0-9.-]{1,253}\.[A-Za-z]{2,6}\b`,
-		// IPv4 addresses
-		`\b(?:[0-9]{1,3}\.){3}[0-9]{1,3}\b`,
-		// Database connection strings
-		`(?i)(?:mysql|postgresql|mongodb)://[^\s]{1,200}\b`,
+	var rules []RedactionRule
+	for _, d := range builtinDetectors {
+		if d.full {
+			rules = append(rules, d.rule)
+		}
 	}
 
-	for _, pattern := range patterns {
-		if err := filter.addPattern(pattern); err != nil {
+	for _, rule := range rules {
+		if err := filter.AddRule(rule); err != nil {
 			// Log error but continue - don't fail initialization for pattern issues
 			continue
 		}
@@ -66,7 +52,7 @@ func NewSensitiveDataFilter() *SensitiveDataFilter {
 
 func NewEmptySensitiveDataFilter() *SensitiveDataFilter {
 	filter := &SensitiveDataFilter{
-		patterns:       make([]*regexp.Regexp, 0),
+		rules:          make([]redactionRule, 0),
 		maxInputLength: MaxInputLength,
 		timeout:        EmptyFilterTimeout,
 	}
@@ -86,26 +72,108 @@ func NewCustomSensitiveDataFilter(patterns ...string) (*SensitiveDataFilter, err
 	return filter, nil
 }
 
-func (f *SensitiveDataFilter) addPattern(pattern string) error {
-	re, err := regexp.Compile(pattern)
+// NewSensitiveDataFilterWithRules creates a filter that applies only the
+// given rules, each with its own masking strategy.
+func NewSensitiveDataFilterWithRules(rules ...RedactionRule) (*SensitiveDataFilter, error) {
+	filter := NewEmptySensitiveDataFilter()
+	if err := filter.AddRules(rules...); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// AddRule adds a named pattern with its own masking strategy. Rules run in
+// the order they are added, ahead of entropy detection.
+func (f *SensitiveDataFilter) AddRule(rule RedactionRule) error {
+	compiled, err := compileRule(rule)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+		return err
 	}
 
 	f.mu.Lock()
-	f.patterns = append(f.patterns, re)
-	f.mu.Unlock()
+	defer f.mu.Unlock()
+	// Entropy detection stays last so that it only sees what the
+	// pattern rules left.
+	if n := len(f.rules); n > 0 && f.rules[n-1].name == EntropyRuleName {
+		f.rules = slices.Insert(slices.Clip(f.rules), n-1, compiled)
+	} else {
+		f.rules = append(f.rules, compiled)
+	}
+
+	return nil
+}
+
+// AddRules adds rules in order, stopping at the first invalid one.
+func (f *SensitiveDataFilter) AddRules(rules ...RedactionRule) error {
+	for _, rule := range rules {
+		if err := f.AddRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMask changes the masking strategy of every rule with the given name,
+// including the built-in rules such as "credit_card" and "email".
+func (f *SensitiveDataFilter) SetMask(name string, mask Mask) error {
+	if err := mask.validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	found := false
+	rules := make([]redactionRule, len(f.rules))
+	for i, rule := range f.rules {
+		if rule.name == name {
+			rule.mask = mask
+			found = true
+		}
+		rules[i] = rule
+	}
+	if !found {
+		return fmt.Errorf("%w: no rule named %q", ErrInvalidPattern, name)
+	}
+	// Replace the slice so that in-flight Filter calls keep a consistent view.
+	f.rules = rules
+	return nil
+}
 
+// SetHashKey sets the secret used by MaskHash rules. keyID is embedded in
+// every token; call SetHashKey again with a new ID to rotate the key.
+func (f *SensitiveDataFilter) SetHashKey(keyID string, key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("%w: hash key cannot be empty", ErrInvalidPattern)
+	}
+	if strings.ContainsAny(keyID, " \t\r\n") {
+		return fmt.Errorf("%w: hash key ID %q contains whitespace", ErrInvalidPattern, keyID)
+	}
+	f.hashKey.Store(&hashKey{id: keyID, key: append([]byte(nil), key...)})
+	return nil
+}
+
+// SetKeyMask sets the mask applied by FilterFieldValue to the values of
+// sensitive keys such as "password" or "token"; the default is FullMask.
+// With HashMask the field key is used as the token label.
+func (f *SensitiveDataFilter) SetKeyMask(mask Mask) error {
+	if err := mask.validate(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.keyMask = mask
+	f.mu.Unlock()
 	return nil
 }
 
+// AddPattern adds an unnamed pattern whose matches are fully redacted.
 func (f *SensitiveDataFilter) AddPattern(pattern string) error {
-	return f.addPattern(pattern)
+	return f.AddRule(RedactionRule{Pattern: pattern})
 }
 
 func (f *SensitiveDataFilter) AddPatterns(patterns ...string) error {
 	for _, pattern := range patterns {
-		if err := f.addPattern(pattern); err != nil {
+		if err := f.AddPattern(pattern); err != nil {
 			return err
 		}
 	}
@@ -114,14 +182,26 @@ func (f *SensitiveDataFilter) AddPatterns(patterns ...string) error {
 
 func (f *SensitiveDataFilter) ClearPatterns() {
 	f.mu.Lock()
-	f.patterns = make([]*regexp.Regexp, 0)
+	f.rules = make([]redactionRule, 0)
 	f.mu.Unlock()
 }
 
+// RuleStats returns the hit and rejection counts of every rule, in order.
+func (f *SensitiveDataFilter) RuleStats() []RuleStats {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	stats := make([]RuleStats, len(f.rules))
+	for i, rule := range f.rules {
+		stats[i] = rule.stats()
+	}
+	return stats
+}
+
 func (f *SensitiveDataFilter) PatternCount() int {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-	return len(f.patterns)
+	return len(f.rules)
 }
 
 func (f *SensitiveDataFilter) Enable() {
@@ -152,12 +232,21 @@ func (f *SensitiveDataFilter) Clone() *SensitiveDataFilter {
 	defer f.mu.RUnlock()
 
 	clone := &SensitiveDataFilter{
-		patterns:       make([]*regexp.Regexp, len(f.patterns)),
+		rules:          make([]redactionRule, len(f.rules)),
 		maxInputLength: f.maxInputLength,
 		timeout:        f.timeout,
+		keyMask:        f.keyMask,
 	}
 	clone.enabled.Store(f.enabled.Load())
-	copy(clone.patterns, f.patterns)
+	clone.hashKey.Store(f.hashKey.Load())
+	clone.keys.Store(f.keys.Load())
+	clone.reporter.Store(f.reporter.Load())
+	clone.dryRun.Store(f.dryRun.Load())
+	clone.encKey.Store(f.encKey.Load())
+	for i, rule := range f.rules {
+		rule.counters = &ruleCounters{}
+		clone.rules[i] = rule
+	}
 
 	return clone
 }
@@ -166,6 +255,11 @@ func (f *SensitiveDataFilter) Filter(input string) string {
 	if f == nil || !f.enabled.Load() {
 		return input
 	}
+	return f.filterString(input, "")
+}
+
+// filterString filters a message (key "") or the value of field key.
+func (f *SensitiveDataFilter) filterString(input, key string) string {
 
 	inputLen := len(input)
 	if inputLen == 0 {
@@ -177,41 +271,37 @@ func (f *SensitiveDataFilter) Filter(input string) string {
 	}
 
 	f.mu.RLock()
-	patternCount := len(f.patterns)
-	if patternCount == 0 {
+	ruleCount := len(f.rules)
+	if ruleCount == 0 {
 		f.mu.RUnlock()
 		return input
 	}
 
-	patterns := make([]*regexp.Regexp, patternCount)
-	copy(patterns, f.patterns)
+	rules := make([]redactionRule, ruleCount)
+	copy(rules, f.rules)
 	timeout := f.timeout
 	f.mu.RUnlock()
 
-	result := input
-	for i := range patternCount {
-		result = f.filterWithTimeout(result, patterns[i], timeout)
+	hk, ek := f.hashKey.Load(), f.encKey.Load()
+	for i := range rules {
+		rules[i].hashKey = hk
+		rules[i].encKey = ek
 	}
 
-	return result
+	return f.filterWithTimeout(input, rules, timeout, f.newAudit(key), ek)
 }
 
-func (f *SensitiveDataFilter) filterWithTimeout(input string, pattern *regexp.Regexp, timeout time.Duration) string {
-	inputLen := len(input)
-
-	// Fast path: small inputs processed directly
-	if inputLen < fastPathThreshold {
-		return pattern.ReplaceAllString(input, "[REDACTED]")
-	}
-
-	// Medium size inputs: chunk processing to avoid timeout
-	if inputLen < 10*fastPathThreshold {
-		return f.filterInChunks(input, pattern)
+// filterWithTimeout runs the redaction engine. Large inputs are bounded by
+// the filter timeout for each rule; on expiry the whole input is redacted.
+func (f *SensitiveDataFilter) filterWithTimeout(input string, rules []redactionRule, timeout time.Duration, audit redactionAudit, ek *encryptionKey) string {
+	// Small and medium inputs are processed directly
+	if len(input) < 10*fastPathThreshold {
+		return redactAll(input, rules, audit, ek)
 	}
 
 	// Large inputs: use timeout protection
 	done := make(chan string, 1)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout*time.Duration(len(rules)))
 	defer cancel()
 
 	go func() {
@@ -224,8 +314,7 @@ func (f *SensitiveDataFilter) filterWithTimeout(input string, pattern *regexp.Re
 			}
 		}()
 
-		// Process large input in chunks
-		output := f.filterInChunks(input, pattern)
+		output := redactAll(input, rules, audit, ek)
 		select {
 		case done <- output:
 		case <-ctx.Done():
@@ -242,29 +331,6 @@ func (f *SensitiveDataFilter) filterWithTimeout(input string, pattern *regexp.Re
 	}
 }
 
-// Process input in chunks to improve performance and avoid timeout
-func (f *SensitiveDataFilter) filterInChunks(input string, pattern *regexp.Regexp) string {
-	const chunkSize = 1024
-	inputLen := len(input)
-
-	if inputLen <= chunkSize {
-		return pattern.ReplaceAllString(input, "[REDACTED]")
-	}
-
-	var result strings.Builder
-	result.Grow(inputLen) // Pre-allocate capacity
-
-	for i := 0; i < inputLen; i += chunkSize {
-		end := min(i+chunkSize, inputLen)
-
-		chunk := input[i:end]
-		filtered := pattern.ReplaceAllString(chunk, "[REDACTED]")
-		result.WriteString(filtered)
-	}
-
-	return result.String()
-}
-
 func (f *SensitiveDataFilter) FilterValue(value any) any {
 	if f == nil || !f.enabled.Load() {
 		return value
@@ -275,43 +341,98 @@ func (f *SensitiveDataFilter) FilterValue(value any) any {
 	return value
 }
 
-var sensitiveKeywords = []string{
-	"password", "passwd", "pwd",
-	"secret", "token", "bearer",
-	"api_key", "apikey", "api-key",
-	"access_key", "accesskey", "access-key",
-	"secret_key", "secretkey", "secret-key",
-	"private_key", "privatekey", "private-key",
-	"auth", "authorization",
-	"credit_card", "creditcard",
-	"ssn", "social_security",
-}
-
-func isSensitiveKey(key string) bool {
-	lowerKey := strings.ToLower(key)
-	for _, keyword := range sensitiveKeywords {
-		if lowerKey == keyword || strings.Contains(lowerKey, keyword) {
-			return true
-		}
-	}
-	return false
+// FilterFieldValue redacts a field value. Strings under a sensitive key are
+// masked whole and other strings are filtered. Maps, slices and structs are
+// walked up to MaxRedactDepth levels and MaxRedactNodes values: nested
+// sensitive keys and fields tagged dd:"redact" are masked, fields tagged
+// dd:"-" are dropped and nested strings are filtered. A walked value that
+// needed redaction is returned as plain maps and slices; otherwise the
+// original value is returned. Other values such as errors and numbers are
+// filtered in their text form, which replaces them if a rule matched.
+func (f *SensitiveDataFilter) FilterFieldValue(key string, value any) any {
+	filtered, _ := f.filterField(key, value)
+	return filtered
 }
 
-func (f *SensitiveDataFilter) FilterFieldValue(key string, value any) any {
+// filterField is FilterFieldValue that also reports whether the value changed.
+func (f *SensitiveDataFilter) filterField(key string, value any) (any, bool) {
 	if f == nil || !f.enabled.Load() {
-		return value
+		return value, false
 	}
 
 	str, ok := value.(string)
 	if !ok {
-		return value
+		switch value.(type) {
+		case error, fmt.Stringer:
+			// Rendered through their methods, not their fields
+			return f.filterScalar(key, value)
+		}
+		if !isNestedValue(value) {
+			return f.filterScalar(key, value)
+		}
+		if f.IsSensitiveKey(key) {
+			audit := f.newAudit(key)
+			audit.report(SensitiveKeyRuleName, "", 0, 0, audit.dryRun)
+			if audit.dryRun {
+				return value, false
+			}
+			return redactedText, true
+		}
+		filtered, changed := f.filterNested(key, value)
+		if f.dryRun.Load() {
+			return value, false
+		}
+		return filtered, changed
 	}
 
-	if isSensitiveKey(key) {
-		return "[REDACTED]"
+	if f.IsSensitiveKey(key) {
+		audit := f.newAudit(key)
+		audit.report(SensitiveKeyRuleName, str, 0, len(str), audit.dryRun)
+		if audit.dryRun {
+			return value, false
+		}
+		f.mu.RLock()
+		mask := f.keyMask
+		f.mu.RUnlock()
+		return maskWhole(mask, audit.hashKey, f.encKey.Load(), key, str), true
 	}
 
-	return f.Filter(str)
+	filtered := f.filterString(str, key)
+	return filtered, filtered != str
+}
+
+// filterScalar filters the text form of a value that is neither a string
+// nor walkable, such as an error, a Stringer or a number.
+func (f *SensitiveDataFilter) filterScalar(key string, value any) (any, bool) {
+	switch value.(type) {
+	case nil, bool:
+		return value, false
+	}
+	str := fmt.Sprint(value)
+	filtered := f.filterString(str, key)
+	if filtered == str {
+		return value, false
+	}
+	return filtered, true
+}
+
+// filterNested walks a structured value stored under key with a
+// TypeConverter.
+func (f *SensitiveDataFilter) filterNested(key string, value any) (any, bool) {
+	f.mu.RLock()
+	redact := &nestedRedaction{filter: f, key: key, keyMask: f.keyMask, hashKey: f.hashKey.Load(), encKey: f.encKey.Load()}
+	f.mu.RUnlock()
+
+	tc := getTypeConverter()
+	defer putTypeConverter(tc)
+	tc.redact = redact
+	tc.maxDepth = MaxRedactDepth
+
+	converted := tc.ConvertValue(value)
+	if !redact.changed {
+		return value, false
+	}
+	return converted, true
 }
 
 type SecurityConfig struct {
@@ -322,30 +443,22 @@ type SecurityConfig struct {
 
 func NewBasicSensitiveDataFilter() *SensitiveDataFilter {
 	filter := &SensitiveDataFilter{
-		patterns:       make([]*regexp.Regexp, 0, 6),
+		rules:          make([]redactionRule, 0, 6),
 		maxInputLength: MaxInputLength, // Use consistent constant
 		timeout:        DefaultFilterTimeout,
 	}
 	filter.enabled.Store(true)
 
 	// Basic security patterns - most common sensitive data types
-	patterns := []string{
-		// Credit card numbers (improved pattern with separators)
-		`\b[0-9]{4}[- ]?[0-9]{4}[- ]?[0-9]{4}[- ]?[0-9]{3,7}\b`,
-		// SSN (strict format)
-		`\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`,
-		// Password fields (atomic groups, length limited)
-		`(?i)(?:password|passwd|pwd)[\s:=]+[^\s]{1,32}\b`,
-		// API keys and tokens (atomic groups, length limited)
-		`(?i)(?:api[_-]?key|token|bearer)[\s:=]+[^\s]{1,128}\b`,
-		// OpenAI API Keys
-		`\bsk-[A-Za-z0-9]{16,48}\b`,
-		// Private keys (bounded content, reduced size for basic filter)
-		`-----BEGIN[^-]{1,20}PRIVATE\s+KEY-----[A-Za-z0-9+/=\s]{1,2000}-----END[^-]{1,20}PRIVATE\s+KEY-----`,
+	var rules []RedactionRule
+	for _, d := range builtinDetectors {
+		if d.basic {
+			rules = append(rules, d.rule)
+		}
 	}
 
-	for _, pattern := range patterns {
-		_ = filter.addPattern(pattern)
+	for _, rule := range rules {
+		_ = filter.AddRule(rule)
 	}
 
 	return filter
@@ -0,0 +1,295 @@
+package dd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeyMatch selects how a SensitiveKey pattern is compared with field keys.
+type KeyMatch int8
+
+const (
+	// KeyContains matches keys that contain the pattern
+	KeyContains KeyMatch = iota
+	// KeyExact matches keys equal to the pattern
+	KeyExact
+	// KeyPrefix matches keys that start with the pattern
+	KeyPrefix
+	// KeyRegex matches keys against the pattern as a regular expression
+	KeyRegex
+)
+
+func (m KeyMatch) String() string {
+	switch m {
+	case KeyContains:
+		return "contains"
+	case KeyExact:
+		return "exact"
+	case KeyPrefix:
+		return "prefix"
+	case KeyRegex:
+		return "regex"
+	default:
+		return "unknown"
+	}
+}
+
+// SensitiveKey describes field keys whose values are always masked.
+type SensitiveKey struct {
+	Pattern string
+	Match   KeyMatch
+	// CaseSensitive disables the default case-insensitive comparison
+	CaseSensitive bool
+	// Except lists words that start with the pattern but are not
+	// sensitive, such as "author" for "auth". It applies to KeyContains and
+	// KeyPrefix, and a word only counts when it ends at the end of the key,
+	// a non-letter or an upper-case letter.
+	Except []string
+}
+
+// defaultSensitiveKeys is the key set every filter starts with. "auth" is
+// matched anywhere in a key except in the words "author" and "authority".
+var defaultSensitiveKeys = []SensitiveKey{
+	{Pattern: "password"}, {Pattern: "passwd"}, {Pattern: "pwd"},
+	{Pattern: "secret"}, {Pattern: "token"}, {Pattern: "bearer"},
+	{Pattern: "api_key"}, {Pattern: "apikey"}, {Pattern: "api-key"},
+	{Pattern: "access_key"}, {Pattern: "accesskey"}, {Pattern: "access-key"},
+	{Pattern: "secret_key"}, {Pattern: "secretkey"}, {Pattern: "secret-key"},
+	{Pattern: "private_key"}, {Pattern: "privatekey"}, {Pattern: "private-key"},
+	{Pattern: "auth", Except: []string{"author", "authors", "authority"}}, {Pattern: "authorization"},
+	{Pattern: "credit_card"}, {Pattern: "creditcard"},
+	{Pattern: "ssn"}, {Pattern: "social_security"},
+}
+
+// sensitiveKey is a compiled SensitiveKey.
+type sensitiveKey struct {
+	SensitiveKey
+	folded string         // pattern lower-cased unless case-sensitive
+	except []string       // Except, lower-cased unless case-sensitive
+	re     *regexp.Regexp // KeyRegex only
+}
+
+func compileSensitiveKey(key SensitiveKey) (sensitiveKey, error) {
+	if key.Pattern == "" {
+		return sensitiveKey{}, fmt.Errorf("%w: sensitive key pattern cannot be empty", ErrInvalidPattern)
+	}
+
+	compiled := sensitiveKey{SensitiveKey: key, folded: key.Pattern}
+	switch key.Match {
+	case KeyContains, KeyExact, KeyPrefix:
+		if !key.CaseSensitive {
+			compiled.folded = strings.ToLower(key.Pattern)
+		}
+		for _, word := range key.Except {
+			if !key.CaseSensitive {
+				word = strings.ToLower(word)
+			}
+			compiled.except = append(compiled.except, word)
+		}
+	case KeyRegex:
+		pattern := key.Pattern
+		if !key.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return sensitiveKey{}, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+		}
+		compiled.re = re
+	default:
+		return sensitiveKey{}, fmt.Errorf("%w: unknown key match mode %d", ErrInvalidPattern, key.Match)
+	}
+	return compiled, nil
+}
+
+// matches reports whether key, and its lower-cased form lower, match.
+func (k sensitiveKey) matches(key, lower string) bool {
+	s := lower
+	if k.CaseSensitive {
+		s = key
+	}
+	switch k.Match {
+	case KeyExact:
+		return s == k.folded
+	case KeyPrefix:
+		return strings.HasPrefix(s, k.folded) && !k.excepted(key, s, 0)
+	case KeyRegex:
+		return k.re.MatchString(key)
+	default:
+		for i := 0; ; i++ {
+			at := strings.Index(s[i:], k.folded)
+			if at < 0 {
+				return false
+			}
+			i += at
+			if !k.excepted(key, s, i) {
+				return true
+			}
+		}
+	}
+}
+
+// excepted reports whether the pattern found at offset at of s, the
+// compared form of key, is the start of an Except word.
+func (k sensitiveKey) excepted(key, s string, at int) bool {
+	for _, word := range k.except {
+		if !strings.HasPrefix(s[at:], word) {
+			continue
+		}
+		end := at + len(word)
+		if end == len(s) {
+			return true
+		}
+		next := s[end]
+		if len(key) == len(s) && key[end] >= 'A' && key[end] <= 'Z' {
+			return true // camelCase boundary
+		}
+		if !(next >= 'a' && next <= 'z' || next >= 'A' && next <= 'Z') {
+			return true
+		}
+	}
+	return false
+}
+
+// keySet is an immutable set of sensitive keys and allowed keys. Filters
+// replace it as a whole on every change, so it can be read without locking
+// and shared between clones.
+type keySet struct {
+	keys  []sensitiveKey
+	allow map[string]struct{} // lower-cased keys that are never masked
+}
+
+var defaultKeySet = func() *keySet {
+	set := &keySet{keys: make([]sensitiveKey, len(defaultSensitiveKeys))}
+	for i, key := range defaultSensitiveKeys {
+		compiled, err := compileSensitiveKey(key)
+		if err != nil {
+			panic(err)
+		}
+		set.keys[i] = compiled
+	}
+	return set
+}()
+
+func (s *keySet) isSensitive(key string) bool {
+	lower := strings.ToLower(key)
+	if _, ok := s.allow[lower]; ok {
+		return false
+	}
+	for _, k := range s.keys {
+		if k.matches(key, lower) {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of s that can be modified.
+func (s *keySet) clone() *keySet {
+	c := &keySet{
+		keys:  append([]sensitiveKey(nil), s.keys...),
+		allow: make(map[string]struct{}, len(s.allow)),
+	}
+	for k := range s.allow {
+		c.allow[k] = struct{}{}
+	}
+	return c
+}
+
+// sensitiveKeys returns the filter's key set, falling back to the defaults.
+func (f *SensitiveDataFilter) sensitiveKeys() *keySet {
+	if set := f.keys.Load(); set != nil {
+		return set
+	}
+	return defaultKeySet
+}
+
+// updateKeys applies change to a copy of the key set and installs it.
+func (f *SensitiveDataFilter) updateKeys(change func(*keySet)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	set := f.sensitiveKeys().clone()
+	change(set)
+	f.keys.Store(set)
+}
+
+// IsSensitiveKey reports whether values stored under key are masked whole.
+func (f *SensitiveDataFilter) IsSensitiveKey(key string) bool {
+	if f == nil {
+		return defaultKeySet.isSensitive(key)
+	}
+	return f.sensitiveKeys().isSensitive(key)
+}
+
+// AddSensitiveKeys adds keys whose values are masked whole, in addition to
+// the defaults such as "password" and "token". Nothing is added if any key
+// is invalid.
+func (f *SensitiveDataFilter) AddSensitiveKeys(keys ...SensitiveKey) error {
+	compiled := make([]sensitiveKey, len(keys))
+	for i, key := range keys {
+		k, err := compileSensitiveKey(key)
+		if err != nil {
+			return err
+		}
+		compiled[i] = k
+	}
+	f.updateKeys(func(set *keySet) {
+		set.keys = append(set.keys, compiled...)
+	})
+	return nil
+}
+
+// RemoveSensitiveKey removes every sensitive key with the given pattern,
+// including default ones, and reports whether any was removed.
+func (f *SensitiveDataFilter) RemoveSensitiveKey(pattern string) bool {
+	removed := false
+	f.updateKeys(func(set *keySet) {
+		kept := set.keys[:0]
+		for _, k := range set.keys {
+			if k.Pattern == pattern {
+				removed = true
+				continue
+			}
+			kept = append(kept, k)
+		}
+		set.keys = kept
+	})
+	return removed
+}
+
+// ClearSensitiveKeys removes every sensitive key, including the defaults.
+func (f *SensitiveDataFilter) ClearSensitiveKeys() {
+	f.updateKeys(func(set *keySet) {
+		set.keys = nil
+	})
+}
+
+// SensitiveKeys returns the filter's sensitive keys in order.
+func (f *SensitiveDataFilter) SensitiveKeys() []SensitiveKey {
+	set := f.sensitiveKeys()
+	keys := make([]SensitiveKey, len(set.keys))
+	for i, k := range set.keys {
+		keys[i] = k.SensitiveKey
+	}
+	return keys
+}
+
+// AllowKeys marks keys, compared case-insensitively, as never sensitive
+// even when they match a sensitive key, e.g. "token_type".
+func (f *SensitiveDataFilter) AllowKeys(keys ...string) {
+	f.updateKeys(func(set *keySet) {
+		for _, key := range keys {
+			set.allow[strings.ToLower(key)] = struct{}{}
+		}
+	})
+}
+
+// DisallowKeys removes keys from the allowlist.
+func (f *SensitiveDataFilter) DisallowKeys(keys ...string) {
+	f.updateKeys(func(set *keySet) {
+		for _, key := range keys {
+			delete(set.allow, strings.ToLower(key))
+		}
+	})
+}
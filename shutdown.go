@@ -0,0 +1,212 @@
+package dd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Shutdowner is implemented by writers with background work (queues,
+// pending compression, network flushes) that can be drained within a deadline.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// WriterError pairs a writer description with the error it returned.
+type WriterError struct {
+	Writer string
+	Err    error
+}
+
+func (e WriterError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Writer, e.Err)
+}
+
+func (e WriterError) Unwrap() error {
+	return e.Err
+}
+
+// ShutdownReport describes what Logger.Shutdown managed to drain.
+type ShutdownReport struct {
+	// Closed is the number of writers that were flushed and closed.
+	Closed int
+	// Failed lists writers whose flush or close returned an error.
+	Failed []WriterError
+	// Pending lists writers still draining when the deadline expired.
+	Pending []string
+}
+
+// Complete reports whether every writer was drained without error.
+func (r *ShutdownReport) Complete() bool {
+	return len(r.Failed) == 0 && len(r.Pending) == 0
+}
+
+// Shutdown stops accepting records, waits for in-flight writes, then drains,
+// flushes and closes all writers in parallel. It returns when every writer is
+// done or ctx expires; writers still draining at that point are listed in the
+// report's Pending field and keep running in the background.
+//
+// The returned error is ctx.Err() if the deadline was hit, otherwise the
+// joined writer errors. Calling Shutdown or Close more than once is a no-op.
+func (l *Logger) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	report := &ShutdownReport{}
+	ran := false
+
+	l.closeOnce.Do(func() {
+		ran = true
+		l.closed.Store(true)
+		l.cancel()
+
+		// Detach the writers without blocking past the deadline
+		detached := make(chan []writerEntry, 1)
+		go func() {
+			l.mu.Lock()
+			entries := l.loadWriters()
+			l.writers.Store(nil)
+			l.mu.Unlock()
+			detached <- entries
+		}()
+
+		select {
+		case entries := <-detached:
+			shutdownWriters(ctx, entries, report)
+		case <-ctx.Done():
+			for _, entry := range l.loadWriters() {
+				report.Pending = append(report.Pending, describeWriter(entry.id, entry.writer))
+			}
+			// Writers detached later keep draining in the background
+			go func() { shutdownWriters(ctx, <-detached, &ShutdownReport{}) }()
+		}
+	})
+
+	if !ran {
+		return report, nil
+	}
+
+	if len(report.Pending) > 0 {
+		return report, ctx.Err()
+	}
+
+	errs := make([]error, len(report.Failed))
+	for i := range report.Failed {
+		errs[i] = report.Failed[i]
+	}
+	return report, errors.Join(errs...)
+}
+
+// Shutdown is the package-level equivalent of Default().Shutdown(ctx).
+func Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	return Default().Shutdown(ctx)
+}
+
+type shutdownResult struct {
+	index int
+	err   error
+}
+
+// indexedEntries wraps writers of a combinator for shutdownWriters, using
+// their position as the ID.
+func indexedEntries(writers []io.Writer) []writerEntry {
+	entries := make([]writerEntry, len(writers))
+	for i, w := range writers {
+		entries[i] = writerEntry{id: i, writer: w}
+	}
+	return entries
+}
+
+// shutdownWriters drains writers concurrently and records the outcome.
+// Writers with in-flight tracking are drained once their writes finish.
+func shutdownWriters(ctx context.Context, entries []writerEntry, report *ShutdownReport) {
+	if len(entries) == 0 {
+		return
+	}
+
+	results := make(chan shutdownResult, len(entries))
+	for i, entry := range entries {
+		go func(i int, entry writerEntry) {
+			if entry.inflight != nil {
+				entry.inflight.Wait()
+			}
+			results <- shutdownResult{index: i, err: shutdownWriter(ctx, entry.writer)}
+		}(i, entry)
+	}
+
+	done := make([]bool, len(entries))
+	for remaining := len(entries); remaining > 0; remaining-- {
+		select {
+		case res := <-results:
+			done[res.index] = true
+			if res.err != nil {
+				entry := entries[res.index]
+				report.Failed = append(report.Failed, WriterError{
+					Writer: describeWriter(entry.id, entry.writer),
+					Err:    res.err,
+				})
+			} else {
+				report.Closed++
+			}
+		case <-ctx.Done():
+			for i, finished := range done {
+				if !finished {
+					report.Pending = append(report.Pending, describeWriter(entries[i].id, entries[i].writer))
+				}
+			}
+			return
+		}
+	}
+}
+
+// shutdownWriter drains a single writer: writers implementing Shutdowner
+// handle the deadline themselves, anything else is synced and closed.
+// Standard streams are synced but never closed.
+func shutdownWriter(ctx context.Context, w io.Writer) error {
+	if s, ok := w.(Shutdowner); ok {
+		return s.Shutdown(ctx)
+	}
+
+	syncErr := syncWriter(w)
+
+	if w == os.Stdout || w == os.Stderr || w == os.Stdin {
+		return syncErr
+	}
+
+	if closer, ok := w.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return syncErr
+}
+
+// describeWriter labels a writer by its ID and type, e.g. "writer[2] (stderr)".
+func describeWriter(id int, w io.Writer) string {
+	switch w {
+	case os.Stdout:
+		return fmt.Sprintf("writer[%d] (stdout)", id)
+	case os.Stderr:
+		return fmt.Sprintf("writer[%d] (stderr)", id)
+	}
+	if fw, ok := w.(*FileWriter); ok {
+		return fmt.Sprintf("writer[%d] (file %s)", id, fw.path)
+	}
+	return fmt.Sprintf("writer[%d] (%T)", id, w)
+}
+
+// waitGroupContext waits for wg or ctx, whichever comes first.
+func waitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,235 @@
+package dd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// SHUTDOWN TESTS
+// ============================================================================
+
+// slowShutdowner blocks in Shutdown until released or ctx is done
+type slowShutdowner struct {
+	release chan struct{}
+}
+
+func (s *slowShutdowner) Write(p []byte) (int, error) { return len(p), nil }
+func (s *slowShutdowner) Shutdown(ctx context.Context) error {
+	select {
+	case <-s.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeErrWriter fails on Close
+type closeErrWriter struct {
+	bytes.Buffer
+}
+
+func (c *closeErrWriter) Close() error { return errors.New("close failed") }
+
+func TestLoggerShutdown(t *testing.T) {
+	var inner bytes.Buffer
+	bw, err := NewBufferedWriter(&inner, 64*1024)
+	if err != nil {
+		t.Fatalf("Failed to create buffered writer: %v", err)
+	}
+
+	logFile := filepath.Join(t.TempDir(), "shutdown.log")
+	fw, err := NewFileWriter(logFile, FileWriterConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create file writer: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{bw, fw}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Info("drain me")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	report, err := logger.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !report.Complete() || report.Closed != 2 {
+		t.Errorf("Expected 2 writers closed cleanly, got %+v", report)
+	}
+
+	if !strings.Contains(inner.String(), "drain me") {
+		t.Errorf("Buffered record should be flushed on shutdown, got: %q", inner.String())
+	}
+	data, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(data), "drain me") {
+		t.Errorf("File record should be present after shutdown, got: %q", data)
+	}
+
+	logger.Info("after shutdown")
+	if strings.Contains(inner.String(), "after shutdown") {
+		t.Error("Logger should not accept records after shutdown")
+	}
+
+	report, err = logger.Shutdown(ctx)
+	if err != nil || report.Closed != 0 {
+		t.Errorf("Second shutdown should be a no-op, got report=%+v err=%v", report, err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("Close after shutdown should be a no-op, got: %v", err)
+	}
+}
+
+func TestFileWriterClosedAfterShutdown(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "closed.log")
+	fw, err := NewFileWriter(logFile, FileWriterConfig{MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("Failed to create file writer: %v", err)
+	}
+	if err := fw.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// A write past maxSize must not rotate and reopen the log
+	if _, err := fw.Write(bytes.Repeat([]byte("x"), 2*1024*1024)); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed, got %v", err)
+	}
+	fw.mu.Lock()
+	err = fw.rotate()
+	fw.mu.Unlock()
+	if !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected rotate to return ErrWriterClosed, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(logFile)); len(entries) != 1 {
+		t.Errorf("Expected only the original log file, got %d entries", len(entries))
+	}
+}
+
+func TestLoggerShutdownDeadline(t *testing.T) {
+	slow := &slowShutdowner{release: make(chan struct{})}
+	defer close(slow.release)
+
+	var fast bytes.Buffer
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&fast, slow}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	report, err := logger.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+	if report.Closed != 1 {
+		t.Errorf("Expected fast writer to close, got %d closed", report.Closed)
+	}
+	if len(report.Pending) != 1 || !strings.Contains(report.Pending[0], "slowShutdowner") {
+		t.Errorf("Expected slow writer to be reported pending, got %v", report.Pending)
+	}
+}
+
+func TestLoggerShutdownHungWriter(t *testing.T) {
+	hung := newBlockingWriter()
+	defer close(hung.release)
+
+	var fast bytes.Buffer
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&fast, hung}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	go logger.Info("never delivered")
+	<-hung.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	report, err := logger.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Shutdown blocked for %v past the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+	if report.Closed != 1 {
+		t.Errorf("Expected fast writer to close, got %d closed", report.Closed)
+	}
+	if len(report.Pending) != 1 || !strings.Contains(report.Pending[0], "blockingWriter") {
+		t.Errorf("Expected hung writer to be reported pending, got %v", report.Pending)
+	}
+}
+
+func TestLoggerShutdownLockTimeout(t *testing.T) {
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&bytes.Buffer{}, &bytes.Buffer{}}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	// Simulate a lock holder that never lets go before the deadline
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	report, err := logger.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+	if report.Closed != 0 || len(report.Pending) != 2 {
+		t.Errorf("Expected every writer to be pending, got %+v", report)
+	}
+}
+
+func TestLoggerShutdownFailures(t *testing.T) {
+	config := DefaultConfig()
+	config.Writers = []io.Writer{&closeErrWriter{}}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	report, err := logger.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "close failed") {
+		t.Errorf("Expected close error, got: %v", err)
+	}
+	if len(report.Failed) != 1 {
+		t.Errorf("Expected 1 failed writer, got %+v", report.Failed)
+	}
+}
+
+func TestMultiWriterShutdown(t *testing.T) {
+	slow := &slowShutdowner{release: make(chan struct{})}
+	defer close(slow.release)
+
+	mw := NewMultiWriter(&bytes.Buffer{}, slow)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := mw.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+}
@@ -0,0 +1,713 @@
+package dd
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	spoolSegmentExt  = ".seg"
+	spoolCursorFile  = "spool.ack"
+	spoolHeaderSize  = 8   // 4-byte length + 4-byte CRC32
+	spoolCursorSize  = 20  // segment + offset + CRC32
+	spoolCommitEvery = 128 // delivered records between cursor writes
+)
+
+var errSpoolCorrupt = errors.New("corrupt spool record")
+
+// SpoolWriterConfig configures a SpoolWriter.
+type SpoolWriterConfig struct {
+	// Dir holds the segment files and the replay cursor.
+	Dir string
+	// Downstream receives records in the order they were written.
+	Downstream io.Writer
+
+	// MaxSegmentBytes is the size at which a new segment is started and
+	// MaxSegments the number of segments kept. Once both are reached new
+	// records are rejected with ErrBufferFull.
+	MaxSegmentBytes int64
+	MaxSegments     int
+
+	// SyncWrites fsyncs every record before Write returns. Without it a
+	// record survives a process crash but not a power loss.
+	SyncWrites bool
+
+	// MinBackoff and MaxBackoff bound the delay between replay attempts
+	// while the downstream writer is failing.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnError is called when a replay attempt fails.
+	OnError func(error)
+}
+
+// SpoolWriter is a write-ahead spool: every record is appended to a segment
+// file under Dir and then replayed to Downstream by a background goroutine.
+// While Downstream is failing, records accumulate on disk and are delivered
+// once it recovers, including after a crash or restart.
+//
+// Delivery is at least once: the replay position is persisted periodically,
+// so after a crash a small number of records may be delivered again.
+type SpoolWriter struct {
+	dir        string
+	downstream io.Writer
+	maxSegment int64
+	maxCount   int
+	syncWrites bool
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	onError    func(error)
+
+	mu       sync.Mutex
+	segments []*spoolSegment // oldest first; the last one is appended to
+	file     *os.File
+	ackSeq   uint64
+	ackOff   int64
+	closed   bool
+
+	// Owned by the replay goroutine.
+	reader    *os.File
+	readerSeq uint64
+	dirty     int
+	backoff   time.Duration
+
+	notify   chan struct{}
+	flushReq chan chan error
+	stop     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	pending      atomic.Int64
+	pendingBytes atomic.Int64
+	replayed     atomic.Uint64
+	dropped      atomic.Uint64
+}
+
+type spoolSegment struct {
+	seq  uint64
+	path string
+	size int64
+}
+
+// SpoolStats reports the state of a SpoolWriter.
+type SpoolStats struct {
+	Segments     int    // segment files on disk
+	Pending      int64  // records waiting for delivery
+	PendingBytes int64  // payload bytes waiting for delivery
+	Replayed     uint64 // records delivered downstream
+	Dropped      uint64 // records rejected because the spool was full
+}
+
+// NewSpoolWriter opens or creates the spool in config.Dir, recovers any
+// records left by a previous run and starts replaying them.
+func NewSpoolWriter(config SpoolWriterConfig) (*SpoolWriter, error) {
+	if config.Downstream == nil {
+		return nil, fmt.Errorf("%w: nil downstream writer", ErrInvalidSpoolConfig)
+	}
+	if config.Dir == "" {
+		return nil, fmt.Errorf("%w: empty directory", ErrInvalidSpoolConfig)
+	}
+	dir, err := validateAndSecurePath(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxSegmentBytes <= 0 {
+		config.MaxSegmentBytes = DefaultSpoolSegmentSize
+	}
+	if config.MaxSegments <= 0 {
+		config.MaxSegments = DefaultSpoolMaxSegments
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultMinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(DefaultMaxBackoff, config.MinBackoff)
+	}
+
+	if err := os.MkdirAll(dir, DirPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sw := &SpoolWriter{
+		dir:        dir,
+		downstream: config.Downstream,
+		maxSegment: config.MaxSegmentBytes,
+		maxCount:   config.MaxSegments,
+		syncWrites: config.SyncWrites,
+		minBackoff: config.MinBackoff,
+		maxBackoff: config.MaxBackoff,
+		onError:    config.OnError,
+		notify:     make(chan struct{}, 1),
+		flushReq:   make(chan chan error),
+		stop:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	if err := sw.recover(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	sw.wg.Add(1)
+	go sw.replayRoutine()
+	sw.signal()
+
+	return sw, nil
+}
+
+// recover loads the cursor and segments, drops segments that were already
+// delivered and truncates any record torn by a crash.
+func (sw *SpoolWriter) recover() error {
+	cursorSeq, cursorOff := sw.loadCursor()
+
+	seqs, err := sw.listSegments()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range seqs {
+		path := sw.segmentPath(seq)
+		if seq < cursorSeq {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove delivered segment: %w", err)
+			}
+			continue
+		}
+
+		from := int64(0)
+		if seq == cursorSeq {
+			from = cursorOff
+		}
+		size, records, bytes, err := scanSpoolSegment(path, from)
+		if err != nil {
+			return err
+		}
+		sw.segments = append(sw.segments, &spoolSegment{seq: seq, path: path, size: size})
+		sw.pending.Add(records)
+		sw.pendingBytes.Add(bytes)
+	}
+
+	if len(sw.segments) == 0 {
+		seg, err := sw.createSegment(max(cursorSeq, 1))
+		if err != nil {
+			return err
+		}
+		sw.segments = append(sw.segments, seg)
+	}
+
+	head := sw.segments[0]
+	sw.ackSeq = head.seq
+	if head.seq == cursorSeq {
+		sw.ackOff = min(cursorOff, head.size)
+	}
+
+	active := sw.segments[len(sw.segments)-1]
+	file, err := os.OpenFile(active.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	sw.file = file
+	return nil
+}
+
+func (sw *SpoolWriter) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(sw.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	var seqs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func (sw *SpoolWriter) segmentPath(seq uint64) string {
+	return filepath.Join(sw.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentExt))
+}
+
+func (sw *SpoolWriter) createSegment(seq uint64) (*spoolSegment, error) {
+	path := sw.segmentPath(seq)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	return &spoolSegment{seq: seq, path: path}, nil
+}
+
+// scanSpoolSegment validates every record in a segment and truncates the
+// file after the last intact one. It returns the valid size and the number
+// of records and payload bytes at or after offset from.
+func scanSpoolSegment(path string, from int64) (size, records, bytes int64, err error) {
+	file, err := os.OpenFile(path, os.O_RDWR, FilePermissions)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+
+	var off int64
+	for off < info.Size() {
+		payload, err := readSpoolRecord(file, off, info.Size())
+		if err != nil {
+			break
+		}
+		if off >= from {
+			records++
+			bytes += int64(len(payload))
+		}
+		off += spoolHeaderSize + int64(len(payload))
+	}
+
+	if off < info.Size() {
+		if err := file.Truncate(off); err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to truncate spool segment: %w", err)
+		}
+	}
+	return off, records, bytes, nil
+}
+
+// readSpoolRecord reads the record at off, which must end at or before limit.
+func readSpoolRecord(r io.ReaderAt, off, limit int64) ([]byte, error) {
+	var header [spoolHeaderSize]byte
+	if _, err := r.ReadAt(header[:], off); err != nil {
+		return nil, errSpoolCorrupt
+	}
+
+	n := int64(binary.BigEndian.Uint32(header[0:4]))
+	if off+spoolHeaderSize+n > limit {
+		return nil, errSpoolCorrupt
+	}
+
+	payload := make([]byte, n)
+	if _, err := r.ReadAt(payload, off+spoolHeaderSize); err != nil {
+		return nil, errSpoolCorrupt
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errSpoolCorrupt
+	}
+	return payload, nil
+}
+
+// loadCursor returns the persisted replay position, or the start of the
+// spool if there is none or it is unreadable.
+func (sw *SpoolWriter) loadCursor() (uint64, int64) {
+	data, err := os.ReadFile(filepath.Join(sw.dir, spoolCursorFile))
+	if err != nil || len(data) != spoolCursorSize {
+		return 0, 0
+	}
+	if crc32.ChecksumIEEE(data[:16]) != binary.BigEndian.Uint32(data[16:]) {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint64(data[0:8]), int64(binary.BigEndian.Uint64(data[8:16]))
+}
+
+// saveCursor persists the replay position with a write-and-rename so a
+// crash leaves either the old or the new cursor.
+func (sw *SpoolWriter) saveCursor(seq uint64, off int64) error {
+	var data [spoolCursorSize]byte
+	binary.BigEndian.PutUint64(data[0:8], seq)
+	binary.BigEndian.PutUint64(data[8:16], uint64(off))
+	binary.BigEndian.PutUint32(data[16:], crc32.ChecksumIEEE(data[:16]))
+
+	path := filepath.Join(sw.dir, spoolCursorFile)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	_, err = file.Write(data[:])
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	return nil
+}
+
+// Write appends p to the active segment. It returns once the record is in
+// the spool, not when it has been delivered.
+func (sw *SpoolWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	record := make([]byte, spoolHeaderSize+len(p))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(p)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(p))
+	copy(record[spoolHeaderSize:], p)
+
+	sw.mu.Lock()
+	if sw.closed {
+		sw.mu.Unlock()
+		return 0, ErrWriterClosed
+	}
+
+	active := sw.segments[len(sw.segments)-1]
+	if active.size > 0 && active.size+int64(len(record)) > sw.maxSegment {
+		if len(sw.segments) >= sw.maxCount {
+			sw.mu.Unlock()
+			sw.dropped.Add(1)
+			return 0, ErrBufferFull
+		}
+		if err := sw.rollLocked(); err != nil {
+			sw.mu.Unlock()
+			return 0, err
+		}
+		active = sw.segments[len(sw.segments)-1]
+	}
+
+	if _, err := sw.file.Write(record); err != nil {
+		// Drop any partial record so the next one starts on a boundary.
+		_ = sw.file.Truncate(active.size)
+		sw.mu.Unlock()
+		return 0, fmt.Errorf("spool write failed: %w", err)
+	}
+	if sw.syncWrites {
+		if err := sw.file.Sync(); err != nil {
+			sw.mu.Unlock()
+			return 0, fmt.Errorf("spool sync failed: %w", err)
+		}
+	}
+	active.size += int64(len(record))
+	sw.pending.Add(1)
+	sw.pendingBytes.Add(int64(len(p)))
+	sw.mu.Unlock()
+
+	sw.signal()
+	return len(p), nil
+}
+
+// rollLocked closes the active segment and starts the next one.
+func (sw *SpoolWriter) rollLocked() error {
+	last := sw.segments[len(sw.segments)-1]
+	seg, err := sw.createSegment(last.seq + 1)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+
+	_ = sw.file.Sync()
+	_ = sw.file.Close()
+	sw.file = file
+	sw.segments = append(sw.segments, seg)
+	return nil
+}
+
+func (sw *SpoolWriter) signal() {
+	select {
+	case sw.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (sw *SpoolWriter) replayRoutine() {
+	defer sw.wg.Done()
+
+	ticker := time.NewTicker(DefaultSpoolCommitInterval)
+	defer ticker.Stop()
+
+	var retry <-chan time.Time
+	for {
+		// While backing off, new records do not trigger an attempt.
+		notify := sw.notify
+		if retry != nil {
+			notify = nil
+		}
+
+		select {
+		case <-notify:
+		case <-retry:
+		case <-ticker.C:
+			if err := sw.commit(); err != nil {
+				sw.reportError(err)
+			}
+			continue
+		case done := <-sw.flushReq:
+			err := sw.replay()
+			retry = sw.nextRetry(err)
+			done <- err
+			continue
+		case <-sw.stop:
+			_ = sw.replay()
+			return
+		}
+		retry = sw.nextRetry(sw.replay())
+	}
+}
+
+// nextRetry resets the backoff after a successful replay, or reports err
+// and returns a timer for the next attempt.
+func (sw *SpoolWriter) nextRetry(err error) <-chan time.Time {
+	if err == nil {
+		sw.backoff = 0
+		return nil
+	}
+	sw.reportError(err)
+	if sw.backoff == 0 {
+		sw.backoff = sw.minBackoff
+	} else {
+		sw.backoff = min(sw.backoff*2, sw.maxBackoff)
+	}
+	return time.After(sw.backoff)
+}
+
+func (sw *SpoolWriter) reportError(err error) {
+	if sw.onError != nil {
+		sw.onError(err)
+	}
+}
+
+// replay delivers records from the cursor until the spool is empty or the
+// downstream writer fails.
+func (sw *SpoolWriter) replay() error {
+	for {
+		if err := sw.ctx.Err(); err != nil {
+			return errors.Join(err, sw.commit())
+		}
+
+		sw.mu.Lock()
+		head := *sw.segments[0]
+		last := len(sw.segments) == 1
+		off := sw.ackOff
+		sw.mu.Unlock()
+
+		if off >= head.size {
+			if last {
+				if sw.dirty >= spoolCommitEvery {
+					return sw.commit()
+				}
+				return nil
+			}
+			if err := sw.advance(head); err != nil {
+				return err
+			}
+			continue
+		}
+
+		payload, err := sw.readAt(head, off)
+		if err != nil {
+			return err
+		}
+
+		n, err := sw.downstream.Write(payload)
+		if err == nil && n < len(payload) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return errors.Join(fmt.Errorf("spool replay: %w", err), sw.commit())
+		}
+
+		sw.mu.Lock()
+		sw.ackOff = off + spoolHeaderSize + int64(len(payload))
+		sw.mu.Unlock()
+		sw.dirty++
+		sw.pending.Add(-1)
+		sw.pendingBytes.Add(-int64(len(payload)))
+		sw.replayed.Add(1)
+	}
+}
+
+// readAt reads the record at off in seg, skipping the rest of the segment
+// if it is corrupt so that replay cannot get stuck on it.
+func (sw *SpoolWriter) readAt(seg spoolSegment, off int64) ([]byte, error) {
+	if sw.reader == nil || sw.readerSeq != seg.seq {
+		if sw.reader != nil {
+			_ = sw.reader.Close()
+		}
+		file, err := os.Open(seg.path)
+		if err != nil {
+			sw.reader = nil
+			return nil, fmt.Errorf("failed to open spool segment: %w", err)
+		}
+		sw.reader, sw.readerSeq = file, seg.seq
+	}
+
+	payload, err := readSpoolRecord(sw.reader, off, seg.size)
+	if err == nil {
+		return payload, nil
+	}
+
+	sw.mu.Lock()
+	sw.ackOff = seg.size
+	sw.mu.Unlock()
+	sw.dirty++
+	return nil, fmt.Errorf("%w in %s at offset %d", err, seg.path, off)
+}
+
+// advance removes the fully delivered head segment.
+func (sw *SpoolWriter) advance(head spoolSegment) error {
+	sw.mu.Lock()
+	sw.segments = sw.segments[1:]
+	sw.ackSeq = sw.segments[0].seq
+	sw.ackOff = 0
+	sw.mu.Unlock()
+
+	sw.dirty++
+	if err := sw.commit(); err != nil {
+		return err
+	}
+
+	if sw.reader != nil && sw.readerSeq == head.seq {
+		_ = sw.reader.Close()
+		sw.reader = nil
+	}
+	if err := os.Remove(head.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove delivered segment: %w", err)
+	}
+	return nil
+}
+
+// commit persists the replay position if it moved.
+func (sw *SpoolWriter) commit() error {
+	if sw.dirty == 0 {
+		return nil
+	}
+	sw.mu.Lock()
+	seq, off := sw.ackSeq, sw.ackOff
+	sw.mu.Unlock()
+
+	if err := sw.saveCursor(seq, off); err != nil {
+		return err
+	}
+	sw.dirty = 0
+	return nil
+}
+
+// Sync flushes the active segment to disk and replays pending records. A
+// delivery error is returned, but the records stay in the spool.
+func (sw *SpoolWriter) Sync() error {
+	sw.mu.Lock()
+	if sw.closed {
+		sw.mu.Unlock()
+		return nil
+	}
+	err := sw.file.Sync()
+	sw.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("spool sync failed: %w", err)
+	}
+
+	done := make(chan error, 1)
+	select {
+	case sw.flushReq <- done:
+		if err := <-done; err != nil {
+			return err
+		}
+	case <-sw.stop:
+		return nil
+	}
+	return syncWriter(sw.downstream)
+}
+
+// Shutdown stops accepting records, replays what it can until ctx is done
+// and then shuts down the downstream writer. Undelivered records remain in
+// the spool for the next start. If ctx expires during a downstream write,
+// Shutdown returns at once and the spool is closed when the write returns.
+func (sw *SpoolWriter) Shutdown(ctx context.Context) error {
+	sw.mu.Lock()
+	if sw.closed {
+		sw.mu.Unlock()
+		return nil
+	}
+	sw.closed = true
+	sw.mu.Unlock()
+
+	close(sw.stop)
+	if err := waitGroupContext(ctx, &sw.wg); err != nil {
+		// Replay may be blocked in a downstream write that does not watch
+		// ctx, so release the spool in the background once it returns.
+		sw.cancel()
+		pending := sw.pending.Load()
+		go func() {
+			sw.wg.Wait()
+			if err := sw.release(ctx); err != nil {
+				sw.reportError(err)
+			}
+		}()
+		return fmt.Errorf("%d records left in spool: %w", pending, err)
+	}
+	sw.cancel()
+	return sw.release(ctx)
+}
+
+// release persists the cursor, closes the spool files and shuts down the
+// downstream writer once replay has stopped.
+func (sw *SpoolWriter) release(ctx context.Context) error {
+	errs := []error{sw.commit()}
+
+	sw.mu.Lock()
+	if err := sw.file.Sync(); err != nil {
+		errs = append(errs, fmt.Errorf("spool sync failed: %w", err))
+	}
+	errs = append(errs, sw.file.Close())
+	sw.mu.Unlock()
+	if sw.reader != nil {
+		_ = sw.reader.Close()
+	}
+
+	errs = append(errs, shutdownWriter(ctx, sw.downstream))
+	return errors.Join(errs...)
+}
+
+// Close is Shutdown without a deadline.
+func (sw *SpoolWriter) Close() error {
+	return sw.Shutdown(context.Background())
+}
+
+// Stats returns the spool size and delivery counters.
+func (sw *SpoolWriter) Stats() SpoolStats {
+	sw.mu.Lock()
+	segments := len(sw.segments)
+	sw.mu.Unlock()
+
+	return SpoolStats{
+		Segments:     segments,
+		Pending:      sw.pending.Load(),
+		PendingBytes: sw.pendingBytes.Load(),
+		Replayed:     sw.replayed.Load(),
+		Dropped:      sw.dropped.Load(),
+	}
+}
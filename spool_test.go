@@ -0,0 +1,311 @@
+package dd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// SPOOL WRITER TESTS
+// ============================================================================
+
+// spoolSink is a concurrency-safe downstream that fails while broken is set
+type spoolSink struct {
+	broken atomic.Bool
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (s *spoolSink) Write(p []byte) (int, error) {
+	if s.broken.Load() {
+		return 0, errors.New("downstream unavailable")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *spoolSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *spoolSink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	return files
+}
+
+func TestSpoolWriterDelivers(t *testing.T) {
+	sink := &spoolSink{}
+	sw, err := NewSpoolWriter(SpoolWriterConfig{Dir: t.TempDir(), Downstream: sink})
+	if err != nil {
+		t.Fatalf("NewSpoolWriter failed: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Writers = []io.Writer{sw}
+	logger, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Info("first")
+	logger.Info("second")
+
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	output := sink.String()
+	if !strings.Contains(output, "first") || strings.Index(output, "first") > strings.Index(output, "second") {
+		t.Errorf("Expected records delivered in order, got %q", output)
+	}
+	if stats := sw.Stats(); stats.Pending != 0 || stats.Replayed != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !sink.closed {
+		t.Error("Shutdown should close the downstream writer")
+	}
+}
+
+func TestSpoolWriterOutageAndRecovery(t *testing.T) {
+	dir := t.TempDir()
+	sink := &spoolSink{}
+	sink.broken.Store(true)
+
+	var reported atomic.Int32
+	sw, err := NewSpoolWriter(SpoolWriterConfig{
+		Dir:             dir,
+		Downstream:      sink,
+		MaxSegmentBytes: 64,
+		MinBackoff:      time.Millisecond,
+		MaxBackoff:      5 * time.Millisecond,
+		OnError:         func(error) { reported.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("NewSpoolWriter failed: %v", err)
+	}
+	defer sw.Close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := sw.Write([]byte("record during outage\n")); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+	if err := sw.Sync(); err == nil {
+		t.Error("Sync should report the downstream failure")
+	}
+	stats := sw.Stats()
+	if stats.Pending != 10 || stats.Segments < 3 {
+		t.Errorf("Expected 10 pending records over several segments, got %+v", stats)
+	}
+	if reported.Load() == 0 {
+		t.Error("Replay failures should be reported")
+	}
+
+	sink.broken.Store(false)
+	if err := sw.Sync(); err != nil {
+		t.Fatalf("Sync after recovery failed: %v", err)
+	}
+	if got := strings.Count(sink.String(), "record during outage"); got != 10 {
+		t.Errorf("Expected 10 delivered records, got %d", got)
+	}
+	if files := segmentFiles(t, dir); len(files) != 1 {
+		t.Errorf("Delivered segments should be removed, got %v", files)
+	}
+}
+
+// closingBlockingWriter is a blockingWriter that signals when it is closed
+type closingBlockingWriter struct {
+	*blockingWriter
+	closed chan struct{}
+}
+
+func (c *closingBlockingWriter) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestSpoolWriterShutdownHungDownstream(t *testing.T) {
+	sink := &closingBlockingWriter{blockingWriter: newBlockingWriter(), closed: make(chan struct{})}
+	sw, err := NewSpoolWriter(SpoolWriterConfig{Dir: t.TempDir(), Downstream: sink})
+	if err != nil {
+		t.Fatalf("NewSpoolWriter failed: %v", err)
+	}
+
+	sw.Write([]byte("stuck downstream\n"))
+	<-sink.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = sw.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Shutdown blocked for %v past the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	// Once the downstream write returns the spool is released in the background
+	close(sink.release)
+	select {
+	case <-sink.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Downstream was not closed after the hung write returned")
+	}
+}
+
+func TestSpoolWriterFull(t *testing.T) {
+	sink := &spoolSink{}
+	sink.broken.Store(true)
+
+	sw, err := NewSpoolWriter(SpoolWriterConfig{
+		Dir:             t.TempDir(),
+		Downstream:      sink,
+		MaxSegmentBytes: 32,
+		MaxSegments:     2,
+		MinBackoff:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewSpoolWriter failed: %v", err)
+	}
+	defer sw.Close()
+
+	record := []byte("0123456789abcdef\n") // 25 bytes with header, one per segment
+	for i := 0; i < 2; i++ {
+		if _, err := sw.Write(record); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+	if _, err := sw.Write(record); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Expected ErrBufferFull, got %v", err)
+	}
+	if stats := sw.Stats(); stats.Dropped != 1 || stats.Pending != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestSpoolWriterSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	sink := &spoolSink{}
+	sink.broken.Store(true)
+
+	config := SpoolWriterConfig{Dir: dir, Downstream: sink, MaxSegmentBytes: 64, MinBackoff: time.Hour}
+	sw, err := NewSpoolWriter(config)
+	if err != nil {
+		t.Fatalf("NewSpoolWriter failed: %v", err)
+	}
+	for _, msg := range []string{"alpha\n", "bravo\n", "charlie\n", "delta\n", "echo\n"} {
+		if _, err := sw.Write([]byte(msg)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	sw.Close()
+
+	// Simulate a crash in the middle of appending a record.
+	files := segmentFiles(t, dir)
+	last := files[len(files)-1]
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Open segment failed: %v", err)
+	}
+	f.Write([]byte{0, 0, 0, 50, 1, 2, 3, 4, 'p', 'a', 'r'})
+	f.Close()
+
+	sink = &spoolSink{}
+	config.Downstream = sink
+	config.MinBackoff = 0
+	sw, err = NewSpoolWriter(config)
+	if err != nil {
+		t.Fatalf("NewSpoolWriter (restart) failed: %v", err)
+	}
+	defer sw.Close()
+
+	if err := sw.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := sink.String(); got != "alpha\nbravo\ncharlie\ndelta\necho\n" {
+		t.Errorf("Expected spooled records replayed once in order, got %q", got)
+	}
+
+	if _, err := sw.Write([]byte("foxtrot\n")); err != nil {
+		t.Fatalf("Write after torn tail failed: %v", err)
+	}
+	if err := sw.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !strings.HasSuffix(sink.String(), "echo\nfoxtrot\n") {
+		t.Errorf("Record after recovery should follow intact data, got %q", sink.String())
+	}
+}
+
+func TestSpoolWriterAckSkipsDelivered(t *testing.T) {
+	dir := t.TempDir()
+	sink := &spoolSink{}
+
+	config := SpoolWriterConfig{Dir: dir, Downstream: sink}
+	sw, err := NewSpoolWriter(config)
+	if err != nil {
+		t.Fatalf("NewSpoolWriter failed: %v", err)
+	}
+	sw.Write([]byte("delivered\n"))
+	if err := sw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	sink = &spoolSink{}
+	config.Downstream = sink
+	sw, err = NewSpoolWriter(config)
+	if err != nil {
+		t.Fatalf("NewSpoolWriter (restart) failed: %v", err)
+	}
+	defer sw.Close()
+
+	if err := sw.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := sink.String(); got != "" {
+		t.Errorf("Acknowledged records should not be replayed, got %q", got)
+	}
+	if stats := sw.Stats(); stats.Pending != 0 {
+		t.Errorf("Expected nothing pending, got %+v", stats)
+	}
+}
+
+func TestSpoolWriterInvalidConfig(t *testing.T) {
+	if _, err := NewSpoolWriter(SpoolWriterConfig{Dir: t.TempDir()}); !errors.Is(err, ErrInvalidSpoolConfig) {
+		t.Errorf("Expected ErrInvalidSpoolConfig for nil downstream, got %v", err)
+	}
+	if _, err := NewSpoolWriter(SpoolWriterConfig{Downstream: &spoolSink{}}); !errors.Is(err, ErrInvalidSpoolConfig) {
+		t.Errorf("Expected ErrInvalidSpoolConfig for empty dir, got %v", err)
+	}
+	if _, err := NewSpoolWriter(SpoolWriterConfig{Dir: "../escape", Downstream: &spoolSink{}}); !errors.Is(err, ErrPathTraversal) {
+		t.Errorf("Expected ErrPathTraversal, got %v", err)
+	}
+}
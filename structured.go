@@ -130,8 +130,10 @@ func needsQuoting(s string) bool {
 }
 
 // Package-level convenience functions for structured logging
+func TraceWith(msg string, fields ...Field) { Default().LogWith(LevelTrace, msg, fields...) }
 func DebugWith(msg string, fields ...Field) { Default().LogWith(LevelDebug, msg, fields...) }
 func InfoWith(msg string, fields ...Field)  { Default().LogWith(LevelInfo, msg, fields...) }
 func WarnWith(msg string, fields ...Field)  { Default().LogWith(LevelWarn, msg, fields...) }
 func ErrorWith(msg string, fields ...Field) { Default().LogWith(LevelError, msg, fields...) }
+func PanicWith(msg string, fields ...Field) { Default().LogWith(LevelPanic, msg, fields...) }
 func FatalWith(msg string, fields ...Field) { Default().LogWith(LevelFatal, msg, fields...) }
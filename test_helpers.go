@@ -6,11 +6,17 @@ import (
 )
 
 // TestBuffer creates a buffer for testing logger output
+//
+// Deprecated: use ddtest.New to assert on structured entries instead of
+// parsing formatted output.
 func TestBuffer() *bytes.Buffer {
 	return &bytes.Buffer{}
 }
 
 // TestConfig creates a test configuration with the provided buffer
+//
+// Deprecated: use ddtest.NewWithConfig to assert on structured entries
+// instead of parsing formatted output.
 func TestConfig(buf *bytes.Buffer) *LoggerConfig {
 	config := DefaultConfig()
 	if buf != nil {
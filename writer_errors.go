@@ -0,0 +1,139 @@
+package dd
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorHandler is called when a writer fails to accept a record.
+// It runs after the logger has released its locks, but it should not log
+// through the same logger to avoid feedback loops on a broken writer.
+type ErrorHandler func(writer io.Writer, err error)
+
+// WriterStats is a point-in-time snapshot of a single writer's health.
+type WriterStats struct {
+	Writer              string
+	Writes              uint64
+	Failures            uint64
+	ConsecutiveFailures uint64
+	Disabled            bool
+	LastError           error `json:"-"`
+	Bytes               uint64
+	Latency             LatencyHistogram
+}
+
+// writerEntry pairs a writer with its failure bookkeeping.
+type writerEntry struct {
+	id          int // stable across removals; labels stats and reports
+	writer      io.Writer
+	entryWriter EntryWriter // non-nil if writer implements EntryWriter
+	health      *writerHealth
+	inflight    *sync.WaitGroup // writes in progress, see Logger.acquireWriters
+}
+
+func newWriterEntry(id int, w io.Writer) writerEntry {
+	ew, _ := w.(EntryWriter)
+	return writerEntry{id: id, writer: w, entryWriter: ew, health: &writerHealth{}, inflight: &sync.WaitGroup{}}
+}
+
+// writerHealth tracks failures for one writer and implements a simple
+// circuit breaker: after threshold consecutive failures the writer is
+// skipped until the retry interval has passed, then a single trial write
+// decides whether it is re-enabled.
+type writerHealth struct {
+	writes        atomic.Uint64
+	failures      atomic.Uint64
+	consecutive   atomic.Uint64
+	disabledUntil atomic.Int64 // unix nanoseconds, 0 when enabled
+	lastErr       atomic.Pointer[error]
+	bytes         atomic.Uint64
+	latency       latencyHistogram
+}
+
+// allow reports whether a write should be attempted now.
+func (h *writerHealth) allow(cooldown time.Duration) bool {
+	until := h.disabledUntil.Load()
+	if until == 0 {
+		return true
+	}
+
+	now := time.Now().UnixNano()
+	if now < until {
+		return false
+	}
+
+	// Half-open: only the goroutine that wins the CAS performs the trial write.
+	return h.disabledUntil.CompareAndSwap(until, now+int64(cooldown))
+}
+
+func (h *writerHealth) recordSuccess(n int) {
+	h.writes.Add(1)
+	h.bytes.Add(uint64(n))
+	if h.consecutive.Load() != 0 {
+		h.consecutive.Store(0)
+	}
+	if h.disabledUntil.Load() != 0 {
+		h.disabledUntil.Store(0)
+	}
+}
+
+func (h *writerHealth) recordFailure(err error, threshold int, cooldown time.Duration) {
+	h.failures.Add(1)
+	h.lastErr.Store(&err)
+	consecutive := h.consecutive.Add(1)
+	if threshold > 0 && consecutive >= uint64(threshold) {
+		h.disabledUntil.Store(time.Now().Add(cooldown).UnixNano())
+	}
+}
+
+func (h *writerHealth) snapshot(name string) WriterStats {
+	stats := WriterStats{
+		Writer:              name,
+		Writes:              h.writes.Load(),
+		Failures:            h.failures.Load(),
+		ConsecutiveFailures: h.consecutive.Load(),
+		Disabled:            h.disabledUntil.Load() > time.Now().UnixNano(),
+		Bytes:               h.bytes.Load(),
+		Latency:             h.latency.snapshot(),
+	}
+	if errPtr := h.lastErr.Load(); errPtr != nil {
+		stats.LastError = *errPtr
+	}
+	return stats
+}
+
+// writeFailure records a writer that did not accept the current record.
+type writeFailure struct {
+	writer io.Writer
+	err    error // nil when the writer was skipped by the circuit breaker
+}
+
+// handleWriteFailures reports failures and sends the record to the fallback
+// writer once, unless the fallback is itself one of the failed writers.
+func (l *Logger) handleWriteFailures(record []byte, failures []writeFailure) {
+	fallbackFailed := false
+	for _, f := range failures {
+		if f.writer == l.fallbackWriter {
+			fallbackFailed = true
+		}
+		if f.err != nil && l.errorHandler != nil {
+			l.errorHandler(f.writer, f.err)
+		}
+	}
+
+	if l.fallbackWriter != nil && !fallbackFailed {
+		_, _ = l.fallbackWriter.Write(record)
+	}
+}
+
+// WriterStats returns health counters for every writer attached to the logger.
+func (l *Logger) WriterStats() []WriterStats {
+	writers := l.loadWriters()
+	stats := make([]WriterStats, len(writers))
+	for i, entry := range writers {
+		stats[i] = entry.health.snapshot(describeWriter(entry.id, entry.writer))
+	}
+	return stats
+}
@@ -3,6 +3,7 @@ package dd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -15,15 +16,45 @@ import (
 	"github.com/cybergodev/dd/internal/filewriter"
 )
 
+// Syncer is implemented by writers that hold data in memory or in the OS
+// page cache and can commit it to durable storage on request.
+type Syncer interface {
+	Sync() error
+}
+
+// flusher matches writers that expose only a Flush method, such as bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// syncWriter flushes w and, where supported, everything it wraps.
+// Standard streams are skipped since fsync on a terminal or pipe fails.
+func syncWriter(w io.Writer) error {
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	switch s := w.(type) {
+	case Syncer:
+		return s.Sync()
+	case flusher:
+		return s.Flush()
+	default:
+		return nil
+	}
+}
+
 type FileWriter struct {
 	path       string
 	maxSize    int64
 	maxAge     time.Duration
 	maxBackups int
 	compress   bool
+	audit      *auditChain // non-nil in audit mode
 
 	mu          sync.Mutex
 	file        *os.File
+	closed      bool
+	cleaning    bool // cleanupRoutine has been started
 	currentSize atomic.Int64
 
 	ctx    context.Context
@@ -36,6 +67,16 @@ type FileWriterConfig struct {
 	MaxAge     time.Duration
 	MaxBackups int
 	Compress   bool
+
+	// Audit enables tamper-evident mode: every record is framed with a
+	// sequence number and a hash chained to the previous record, and a
+	// checkpoint is written at every rotation. Check the result with
+	// VerifyAuditLog.
+	Audit bool
+	// AuditKey, if set, makes the chain an HMAC-SHA256 under this key
+	// instead of plain SHA-256. Without a key anyone who can edit the file
+	// can recompute the chain, so it only detects accidental damage.
+	AuditKey []byte
 }
 
 func NewFileWriter(path string, config FileWriterConfig) (*FileWriter, error) {
@@ -74,9 +115,23 @@ func NewFileWriter(path string, config FileWriterConfig) (*FileWriter, error) {
 	fw.file = file
 	fw.currentSize.Store(size)
 
+	if config.Audit {
+		chain, segment, err := resumeAuditChain(securePath, config.AuditKey)
+		if err == nil && segment != nil {
+			var n int
+			n, err = file.Write(segment)
+			fw.currentSize.Add(int64(n))
+		}
+		if err != nil {
+			_ = file.Close()
+			cancel()
+			return nil, err
+		}
+		fw.audit = chain
+	}
+
 	if fw.maxAge > 0 {
-		fw.wg.Add(1)
-		go fw.cleanupRoutine()
+		fw.startCleanupLocked()
 	}
 
 	return fw, nil
@@ -144,6 +199,13 @@ func (fw *FileWriter) Write(p []byte) (int, error) {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
+	if fw.closed {
+		return 0, ErrWriterClosed
+	}
+	if fw.audit != nil {
+		return fw.writeAudit(p)
+	}
+
 	if filewriter.NeedsRotation(fw.currentSize.Load(), int64(pLen), fw.maxSize) {
 		if err := fw.rotate(); err != nil {
 			return 0, fmt.Errorf("rotation failed: %w", err)
@@ -159,8 +221,102 @@ func (fw *FileWriter) Write(p []byte) (int, error) {
 	return n, nil
 }
 
+// writeAudit frames p as the next record of the audit chain. The chain
+// only advances once the framed record has been written in full, so a
+// failed write can be retried without leaving a gap; a partial write is
+// reported and shows up as a broken record in VerifyAuditLog.
+// The caller must hold fw.mu.
+func (fw *FileWriter) writeAudit(p []byte) (int, error) {
+	if fw.file == nil {
+		return 0, ErrWriterClosed
+	}
+
+	next := *fw.audit
+	framed := next.frame(p)
+	if filewriter.NeedsRotation(fw.currentSize.Load(), int64(len(framed)), fw.maxSize) {
+		if err := fw.rotate(); err != nil {
+			return 0, fmt.Errorf("rotation failed: %w", err)
+		}
+	}
+
+	n, err := fw.file.Write(framed)
+	fw.currentSize.Add(int64(n))
+	if err != nil {
+		return 0, fmt.Errorf("write failed: %w", err)
+	}
+
+	*fw.audit = next
+	return len(p), nil
+}
+
+// Sync commits the current file contents to stable storage.
+func (fw *FileWriter) Sync() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.file == nil {
+		return nil
+	}
+	return fw.file.Sync()
+}
+
+// setLimits updates rotation and retention settings of an open writer.
+// The new limits apply from the next write onward; setting MaxAge on a
+// writer created without one starts the cleanup routine.
+func (fw *FileWriter) setLimits(config FileWriterConfig) {
+	if err := validateFileWriterConfig(&config); err != nil {
+		return
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.maxSize = int64(config.MaxSizeMB) * 1024 * 1024
+	fw.maxAge = config.MaxAge
+	fw.maxBackups = config.MaxBackups
+	fw.compress = config.Compress
+	if fw.maxAge > 0 && !fw.closed {
+		fw.startCleanupLocked()
+	}
+}
+
+// startCleanupLocked starts the cleanup routine unless it is already
+// running. The caller must hold fw.mu or own fw exclusively.
+func (fw *FileWriter) startCleanupLocked() {
+	if fw.cleaning {
+		return
+	}
+	fw.cleaning = true
+	fw.wg.Add(1)
+	go fw.cleanupRoutine()
+}
+
+// Shutdown syncs and closes the file, then waits for background
+// compression and cleanup until ctx is done.
+func (fw *FileWriter) Shutdown(ctx context.Context) error {
+	fw.cancel()
+
+	fw.mu.Lock()
+	fw.closed = true
+	var err error
+	if fw.file != nil {
+		syncErr := fw.file.Sync()
+		err = errors.Join(syncErr, fw.file.Close())
+		fw.file = nil
+	}
+	fw.mu.Unlock()
+
+	if waitErr := waitGroupContext(ctx, &fw.wg); waitErr != nil {
+		return errors.Join(err, fmt.Errorf("background compression still running: %w", waitErr))
+	}
+	return err
+}
+
 func (fw *FileWriter) Close() error {
 	fw.cancel()
+	fw.mu.Lock()
+	fw.closed = true
+	fw.mu.Unlock()
 	fw.wg.Wait()
 
 	fw.mu.Lock()
@@ -175,6 +331,17 @@ func (fw *FileWriter) Close() error {
 }
 
 func (fw *FileWriter) rotate() error {
+	if fw.closed {
+		return ErrWriterClosed
+	}
+
+	if fw.file != nil && fw.audit != nil {
+		checkpoint := fw.audit.signedLine(auditItemCheckpoint)
+		if _, err := fw.file.Write(checkpoint); err != nil {
+			return fmt.Errorf("write audit checkpoint: %w", err)
+		}
+	}
+
 	if fw.file != nil {
 		if err := fw.file.Close(); err != nil {
 			return fmt.Errorf("close file during rotation: %w", err)
@@ -190,7 +357,9 @@ func (fw *FileWriter) rotate() error {
 		return fmt.Errorf("rotate backups: %w", err)
 	}
 
-	nextIndex := filewriter.FindNextBackupIndex(fw.path, fw.compress)
+	// Consider both forms so that a backup still being compressed is not
+	// overwritten by the next rotation.
+	nextIndex := max(filewriter.FindNextBackupIndex(fw.path, false), filewriter.FindNextBackupIndex(fw.path, true))
 	backupPath := filewriter.GetBackupPath(fw.path, nextIndex, false)
 
 	if err := os.Rename(fw.path, backupPath); err != nil {
@@ -218,6 +387,14 @@ func (fw *FileWriter) rotate() error {
 	fw.file = file
 	fw.currentSize.Store(size)
 
+	if fw.audit != nil {
+		n, err := file.Write(fw.audit.signedLine(auditItemAnchor))
+		fw.currentSize.Add(int64(n))
+		if err != nil {
+			return fmt.Errorf("write audit anchor: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -232,7 +409,10 @@ func (fw *FileWriter) cleanupRoutine() {
 		case <-fw.ctx.Done():
 			return
 		case <-ticker.C:
-			filewriter.CleanupOldFiles(fw.path, fw.maxAge)
+			fw.mu.Lock()
+			maxAge := fw.maxAge
+			fw.mu.Unlock()
+			filewriter.CleanupOldFiles(fw.path, maxAge)
 		}
 	}
 }
@@ -314,6 +494,14 @@ func (bw *BufferedWriter) Flush() error {
 	return err
 }
 
+// Sync flushes buffered data and then syncs the underlying writer.
+func (bw *BufferedWriter) Sync() error {
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return syncWriter(bw.writer)
+}
+
 func (bw *BufferedWriter) Close() error {
 	if !bw.closed.CompareAndSwap(false, true) {
 		return nil
@@ -341,6 +529,25 @@ func (bw *BufferedWriter) Close() error {
 	return nil
 }
 
+// Shutdown flushes buffered data and shuts down the underlying writer,
+// giving up when ctx is done.
+func (bw *BufferedWriter) Shutdown(ctx context.Context) error {
+	if !bw.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	bw.cancel()
+	if err := waitGroupContext(ctx, &bw.wg); err != nil {
+		return err
+	}
+
+	bw.mu.Lock()
+	flushErr := bw.buffer.Flush()
+	bw.mu.Unlock()
+
+	return errors.Join(flushErr, shutdownWriter(ctx, bw.writer))
+}
+
 func (bw *BufferedWriter) autoFlushRoutine() {
 	defer bw.wg.Done()
 
@@ -460,6 +667,42 @@ func (mw *MultiWriter) RemoveWriter(w io.Writer) {
 	}
 }
 
+// Sync syncs every wrapped writer, returning all errors joined.
+func (mw *MultiWriter) Sync() error {
+	mw.mu.RLock()
+	writers := make([]io.Writer, len(mw.writers))
+	copy(writers, mw.writers)
+	mw.mu.RUnlock()
+
+	var errs []error
+	for i, w := range writers {
+		if err := syncWriter(w); err != nil {
+			errs = append(errs, fmt.Errorf("writer[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown drains and closes every wrapped writer in parallel.
+func (mw *MultiWriter) Shutdown(ctx context.Context) error {
+	mw.mu.RLock()
+	writers := make([]io.Writer, len(mw.writers))
+	copy(writers, mw.writers)
+	mw.mu.RUnlock()
+
+	report := &ShutdownReport{}
+	shutdownWriters(ctx, indexedEntries(writers), report)
+
+	errs := make([]error, 0, len(report.Failed)+1)
+	for _, failed := range report.Failed {
+		errs = append(errs, failed)
+	}
+	if len(report.Pending) > 0 {
+		errs = append(errs, fmt.Errorf("%d writers still draining: %w", len(report.Pending), ctx.Err()))
+	}
+	return errors.Join(errs...)
+}
+
 func (mw *MultiWriter) Close() error {
 	mw.mu.RLock()
 	writers := make([]io.Writer, len(mw.writers))